@@ -8,6 +8,7 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"time"
 )
 
 var (
@@ -32,12 +33,35 @@ type WeatherAPIResponse struct {
 	} `json:"current"`
 }
 
+// Fixture de temperatura usada no modo de teste, no mesmo formato do service-b
+type WeatherFixture struct {
+	City      string  `json:"city"`
+	TempC     float64 `json:"temp_c"`
+	LatencyMs int     `json:"latency_ms"` // Atraso simulado antes da resposta
+	Error     string  `json:"error"`      // Simula uma falha de rede
+	Status    int     `json:"status"`     // Simula um status HTTP de erro
+}
+
+// Fixtures do modo de teste; sem arquivo, todas as cidades respondem 25°C
+var weatherFixtures = struct {
+	Default *WeatherFixture  `json:"default"`
+	Entries []WeatherFixture `json:"entries"`
+}{
+	Default: &WeatherFixture{TempC: 25.0},
+}
+
 func main() {
 	// Verificar se estamos em modo de teste a partir de variável de ambiente
 	testModeEnv := os.Getenv("TEST_MODE")
 	if testModeEnv == "true" {
 		testMode = true
 		log.Println("Iniciando em modo de teste")
+
+		if path := os.Getenv("WEATHER_FIXTURES_FILE"); path != "" {
+			if err := loadWeatherFixtures(path); err != nil {
+				log.Fatalf("Erro ao carregar fixtures de clima: %v", err)
+			}
+		}
 	}
 
 	// Configurar rotas
@@ -149,10 +173,10 @@ func getCityByCEP(cep string) (string, error) {
 }
 
 func getTemperature(cidade string) (float64, error) {
-	// Modo de teste retorna valor fictício para facilitar testes
+	// Modo de teste responde a partir das fixtures, sem chamar a API
 	if testMode {
 		log.Printf("Usando modo de teste para cidade: %s", cidade)
-		return 25.0, nil
+		return fixtureTemperature(cidade)
 	}
 
 	apiKey := os.Getenv("WEATHER_API_KEY")
@@ -187,6 +211,39 @@ func getTemperature(cidade string) (float64, error) {
 	return weatherResp.Current.TempC, nil
 }
 
+// Carrega as fixtures do modo de teste a partir de um arquivo JSON
+func loadWeatherFixtures(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	weatherFixtures.Default = nil
+	return json.Unmarshal(data, &weatherFixtures)
+}
+
+// Retorna a temperatura da fixture da cidade, simulando latência e erros
+func fixtureTemperature(cidade string) (float64, error) {
+	fixture := weatherFixtures.Default
+	for i := range weatherFixtures.Entries {
+		if strings.EqualFold(removeAccents(weatherFixtures.Entries[i].City), removeAccents(cidade)) {
+			fixture = &weatherFixtures.Entries[i]
+			break
+		}
+	}
+	if fixture == nil {
+		return 0, fmt.Errorf("no weather fixture for %s", cidade)
+	}
+
+	time.Sleep(time.Duration(fixture.LatencyMs) * time.Millisecond)
+	if fixture.Error != "" {
+		return 0, fmt.Errorf("%s", fixture.Error)
+	}
+	if fixture.Status != 0 {
+		return 0, fmt.Errorf("Error getting weather data: status %d", fixture.Status)
+	}
+	return fixture.TempC, nil
+}
+
 // Função para remover acentos de uma string
 func removeAccents(s string) string {
 	replacements := map[string]string{
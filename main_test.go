@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -130,3 +132,28 @@ func TestHealthCheck(t *testing.T) {
 		t.Errorf("Resposta incorreta: %v", response)
 	}
 }
+
+func TestFixtureTemperature(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	content := `{"entries": [{"city": "São Paulo", "temp_c": 22.5}, {"city": "Recife", "status": 503}]}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	original := weatherFixtures
+	defer func() { weatherFixtures = original }()
+
+	if err := loadWeatherFixtures(path); err != nil {
+		t.Fatalf("Erro ao carregar fixtures: %v", err)
+	}
+
+	if temp, err := fixtureTemperature("Sao Paulo"); err != nil || temp != 22.5 {
+		t.Errorf("Temperatura incorreta: obtido %v (%v), esperado 22.5", temp, err)
+	}
+	if _, err := fixtureTemperature("Recife"); err == nil {
+		t.Errorf("Esperado erro para fixture com status de erro")
+	}
+	if _, err := fixtureTemperature("Natal"); err == nil {
+		t.Errorf("Esperado erro para cidade sem fixture")
+	}
+}
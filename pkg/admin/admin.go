@@ -0,0 +1,90 @@
+// Package admin reúne o que os endpoints administrativos dos serviços têm em comum:
+// a autenticação pelo token de ADMIN_TOKEN, o log de auditoria e a administração das
+// regras de injeção de falhas.
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"cep-weather-api/pkg/faults"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Require protege os endpoints administrativos com o token de ADMIN_TOKEN, enviado
+// no cabeçalho X-Admin-Token ou como "Authorization: Bearer <token>". Sem
+// ADMIN_TOKEN configurado, os endpoints ficam desativados.
+func Require(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		expected := os.Getenv("ADMIN_TOKEN")
+		if expected == "" {
+			http.Error(w, "admin API disabled", http.StatusForbidden)
+			return
+		}
+
+		token := r.Header.Get("X-Admin-Token")
+		if token == "" {
+			token = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// HandleFaults consulta (GET), substitui (PUT ou POST, com uma lista de regras em
+// JSON) ou remove (DELETE) as regras de injeção de falhas
+func HandleFaults(injector *faults.Injector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var rules []faults.Rule
+			if err := json.NewDecoder(r.Body).Decode(&rules); err != nil {
+				http.Error(w, "invalid request format", http.StatusBadRequest)
+				return
+			}
+			if err := injector.SetRules(rules); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			Audit(r.Context(), r, "faults.update", attribute.Int("faults.rules", len(rules)))
+		case http.MethodDelete:
+			injector.SetRules(nil)
+			Audit(r.Context(), r, "faults.clear")
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(injector.Rules())
+	}
+}
+
+// Audit registra uma operação administrativa no log de auditoria e como evento no
+// span da requisição
+func Audit(ctx context.Context, r *http.Request, action string, attrs ...attribute.KeyValue) {
+	attrs = append([]attribute.KeyValue{
+		attribute.String("admin.action", action),
+		attribute.String("admin.client", r.RemoteAddr),
+	}, attrs...)
+	trace.SpanFromContext(ctx).AddEvent("admin-audit", trace.WithAttributes(attrs...))
+
+	details := make([]string, 0, len(attrs))
+	for _, attr := range attrs[2:] {
+		details = append(details, fmt.Sprintf("%s=%s", attr.Key, attr.Value.Emit()))
+	}
+	log.Printf("AUDITORIA: %s por %s %s", action, r.RemoteAddr, strings.Join(details, " "))
+}
@@ -0,0 +1,78 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cep-weather-api/pkg/faults"
+)
+
+func TestRequire(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string // ADMIN_TOKEN do serviço
+		header string
+		value  string
+		want   int
+	}{
+		{"API desativada", "", "X-Admin-Token", "segredo", http.StatusForbidden},
+		{"sem token", "segredo", "", "", http.StatusUnauthorized},
+		{"token incorreto", "segredo", "X-Admin-Token", "outro", http.StatusUnauthorized},
+		{"cabeçalho X-Admin-Token", "segredo", "X-Admin-Token", "segredo", http.StatusOK},
+		{"token Bearer", "segredo", "Authorization", "Bearer segredo", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ADMIN_TOKEN", tt.token)
+			req := httptest.NewRequest(http.MethodGet, "/admin/faults", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			Require(func(w http.ResponseWriter, r *http.Request) {})(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("Status incorreto: obtido %d, esperado %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleFaults(t *testing.T) {
+	injector := faults.NewInjector()
+	handler := HandleFaults(injector)
+
+	do := func(method, body string) (int, []faults.Rule) {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(method, "/admin/faults", strings.NewReader(body)))
+		var rules []faults.Rule
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&rules); err != nil {
+				t.Fatalf("Resposta inválida: %v", err)
+			}
+		}
+		return rec.Code, rules
+	}
+
+	if code, rules := do(http.MethodPut, `[{"provider": "viacep", "status": 503}]`); code != http.StatusOK || len(rules) != 1 || rules[0].ID != "rule-1" {
+		t.Fatalf("Regras não atualizadas: %d %+v", code, rules)
+	}
+	if code, rules := do(http.MethodGet, ""); code != http.StatusOK || len(rules) != 1 {
+		t.Errorf("Consulta incorreta: %d %+v", code, rules)
+	}
+	if code, _ := do(http.MethodPut, `[{"cep_pattern": "["}]`); code != http.StatusBadRequest {
+		t.Errorf("Regra inválida deveria ser recusada, obtido %d", code)
+	}
+	if code, _ := do(http.MethodPut, `{`); code != http.StatusBadRequest {
+		t.Errorf("JSON inválido deveria ser recusado, obtido %d", code)
+	}
+	if code, rules := do(http.MethodDelete, ""); code != http.StatusOK || len(rules) != 0 {
+		t.Errorf("Regras não removidas: %d %+v", code, rules)
+	}
+	if code, _ := do(http.MethodPatch, ""); code != http.StatusMethodNotAllowed {
+		t.Errorf("Método não suportado deveria ser recusado, obtido %d", code)
+	}
+}
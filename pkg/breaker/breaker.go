@@ -0,0 +1,310 @@
+// Package breaker implementa circuit breakers para as dependências externas. Depois de
+// FailureThreshold falhas seguidas o circuito abre e as chamadas falham na hora, sem
+// esperar pela dependência; passado OpenTimeout, até HalfOpenProbes chamadas de teste
+// passam (meio aberto) e o circuito fecha quando todas dão certo, ou reabre na primeira
+// falha.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrOpen indica uma chamada recusada porque o circuito da dependência está aberto
+var ErrOpen = errors.New("circuit breaker open")
+
+// State é o estado de um circuito
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Config define quando o circuito abre e como ele é testado antes de fechar
+type Config struct {
+	FailureThreshold int           // Falhas seguidas que abrem o circuito
+	OpenTimeout      time.Duration // Tempo aberto antes das chamadas de teste
+	HalfOpenProbes   int           // Chamadas de teste simultâneas, todas com sucesso para fechar
+
+	// IsFailure informa se o resultado de uma chamada feita pelo Transport conta como
+	// falha da dependência; nil conta os erros e os status 5xx
+	IsFailure func(resp *http.Response, err error) bool
+}
+
+// FromEnv lê a configuração das variáveis <prefix>BREAKER_FAILURE_THRESHOLD,
+// <prefix>BREAKER_OPEN_TIMEOUT e <prefix>BREAKER_HALF_OPEN_PROBES, usando os valores
+// de def para as ausentes ou inválidas
+func FromEnv(prefix string, def Config) Config {
+	config := def
+	if v, err := strconv.Atoi(os.Getenv(prefix + "BREAKER_FAILURE_THRESHOLD")); err == nil {
+		config.FailureThreshold = v
+	}
+	if v, err := time.ParseDuration(os.Getenv(prefix + "BREAKER_OPEN_TIMEOUT")); err == nil {
+		config.OpenTimeout = v
+	}
+	if v, err := strconv.Atoi(os.Getenv(prefix + "BREAKER_HALF_OPEN_PROBES")); err == nil && v > 0 {
+		config.HalfOpenProbes = v
+	}
+	return config
+}
+
+// Breaker é o circuito de uma dependência. Com FailureThreshold <= 0 ele nunca abre.
+type Breaker struct {
+	name   string
+	config Config
+	now    func() time.Time
+
+	mu            sync.Mutex
+	state         State
+	failures      int // Falhas seguidas no estado fechado
+	probes        int // Chamadas de teste em andamento no estado meio aberto
+	successes     int // Chamadas de teste com sucesso no estado meio aberto
+	openedAt      time.Time
+	opens         uint64
+	shortCircuits uint64
+}
+
+// Snapshot descreve o estado de um circuito para as métricas e o health check
+type Snapshot struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Opens               uint64     `json:"opens"`
+	ShortCircuits       uint64     `json:"short_circuits"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+}
+
+// New cria o circuito da dependência informada, inicialmente fechado
+func New(name string, config Config) *Breaker {
+	if config.HalfOpenProbes < 1 {
+		config.HalfOpenProbes = 1
+	}
+	return &Breaker{name: name, config: config, now: time.Now}
+}
+
+// Name retorna o nome da dependência
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Allow informa se a chamada pode seguir; com o circuito aberto retorna ErrOpen
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open && b.now().Sub(b.openedAt) >= b.config.OpenTimeout {
+		b.transition(HalfOpen)
+	}
+	switch b.state {
+	case Open:
+		b.shortCircuits++
+		return fmt.Errorf("%w: %s", ErrOpen, b.name)
+	case HalfOpen:
+		if b.probes >= b.config.HalfOpenProbes {
+			b.shortCircuits++
+			return fmt.Errorf("%w: %s", ErrOpen, b.name)
+		}
+		b.probes++
+	}
+	return nil
+}
+
+// Record registra o resultado de uma chamada permitida por Allow
+func (b *Breaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Closed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.config.FailureThreshold > 0 && b.failures >= b.config.FailureThreshold {
+			log.Printf("Circuito de %s aberto após %d falhas seguidas", b.name, b.failures)
+			b.transition(Open)
+		}
+	case HalfOpen:
+		if b.probes > 0 {
+			b.probes--
+		}
+		if !success {
+			log.Printf("Chamada de teste a %s falhou, circuito aberto novamente", b.name)
+			b.transition(Open)
+			return
+		}
+		b.successes++
+		if b.successes >= b.config.HalfOpenProbes {
+			log.Printf("Circuito de %s fechado após %d chamadas de teste", b.name, b.successes)
+			b.transition(Closed)
+		}
+	}
+}
+
+// transition muda o estado, zerando os contadores do estado anterior
+func (b *Breaker) transition(state State) {
+	b.state = state
+	b.failures, b.probes, b.successes = 0, 0, 0
+	if state == Open {
+		b.openedAt = b.now()
+		b.opens++
+	}
+}
+
+// State retorna o estado atual do circuito
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Snapshot retorna o estado e os contadores do circuito
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := Snapshot{
+		State:               b.state.String(),
+		ConsecutiveFailures: b.failures,
+		Opens:               b.opens,
+		ShortCircuits:       b.shortCircuits,
+	}
+	if b.state != Closed {
+		openedAt := b.openedAt
+		snapshot.OpenedAt = &openedAt
+	}
+	return snapshot
+}
+
+// Transport envolve next com o circuito: chamadas recusadas retornam ErrOpen e geram o
+// evento "circuit-open" no span da chamada. Falhas de rede, timeouts e status 5xx contam
+// como falha, a menos que Config.IsFailure diga outra coisa; chamadas canceladas pelo
+// chamador não contam.
+func (b *Breaker) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{breaker: b, next: next}
+}
+
+type transport struct {
+	breaker *Breaker
+	next    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if err := t.breaker.Allow(); err != nil {
+		trace.SpanFromContext(ctx).AddEvent("circuit-open", trace.WithAttributes(
+			attribute.String("breaker.name", t.breaker.name),
+			attribute.String("breaker.state", t.breaker.State().String()),
+		))
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	switch {
+	case err != nil && errors.Is(ctx.Err(), context.Canceled):
+		// Cancelada pelo chamador (por exemplo, a perdedora de um hedge): não diz nada
+		// sobre a dependência, mas libera a vaga de teste
+		t.breaker.release()
+	default:
+		t.breaker.Record(!t.breaker.isFailure(resp, err))
+	}
+	return resp, err
+}
+
+// isFailure classifica o resultado da chamada conforme Config.IsFailure ou, sem ela,
+// conta os erros e os status 5xx
+func (b *Breaker) isFailure(resp *http.Response, err error) bool {
+	if b.config.IsFailure != nil {
+		return b.config.IsFailure(resp, err)
+	}
+	return err != nil || resp.StatusCode >= 500
+}
+
+// release devolve a vaga de uma chamada de teste sem registrar o resultado
+func (b *Breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == HalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+// Registry reúne os circuitos das dependências de um serviço, criados sob demanda
+type Registry struct {
+	config func(name string) Config
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// NewRegistry cria um registro cujos circuitos usam a configuração retornada por config
+func NewRegistry(config func(name string) Config) *Registry {
+	return &Registry{config: config, breakers: make(map[string]*Breaker)}
+}
+
+// Get retorna o circuito da dependência, criando-o na primeira chamada
+func (r *Registry) Get(name string) *Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.breakers[name]
+	if !ok {
+		b = New(name, r.config(name))
+		r.breakers[name] = b
+	}
+	return b
+}
+
+// Snapshots retorna o estado de cada circuito, pelo nome da dependência
+func (r *Registry) Snapshots() map[string]Snapshot {
+	if r == nil {
+		return map[string]Snapshot{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshots := make(map[string]Snapshot, len(r.breakers))
+	for name, b := range r.breakers {
+		snapshots[name] = b.Snapshot()
+	}
+	return snapshots
+}
+
+// Open retorna, em ordem alfabética, as dependências com o circuito aberto ou meio aberto
+func (r *Registry) Open() []string {
+	var names []string
+	for name, snapshot := range r.Snapshots() {
+		if snapshot.State != Closed.String() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,138 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestBreakerStates(t *testing.T) {
+	now := time.Now()
+	b := New("weatherapi", Config{FailureThreshold: 3, OpenTimeout: 10 * time.Second, HalfOpenProbes: 2})
+	b.now = func() time.Time { return now }
+
+	call := func(success bool) error {
+		t.Helper()
+		if err := b.Allow(); err != nil {
+			return err
+		}
+		b.Record(success)
+		return nil
+	}
+
+	// Um sucesso zera a contagem de falhas seguidas
+	call(false)
+	call(false)
+	call(true)
+	call(false)
+	call(false)
+	if b.State() != Closed {
+		t.Fatalf("Circuito deveria continuar fechado, está %s", b.State())
+	}
+	call(false)
+	if b.State() != Open {
+		t.Fatalf("Circuito deveria abrir após 3 falhas seguidas, está %s", b.State())
+	}
+	if err := call(true); !errors.Is(err, ErrOpen) {
+		t.Errorf("Chamada com o circuito aberto deveria ser recusada: %v", err)
+	}
+
+	// Passado o OpenTimeout, as chamadas de teste passam e uma falha reabre o circuito
+	now = now.Add(10 * time.Second)
+	call(false)
+	if b.State() != Open {
+		t.Fatalf("Falha no teste deveria reabrir o circuito, está %s", b.State())
+	}
+
+	// Sucesso em todas as chamadas de teste fecha o circuito; além delas, nenhuma passa
+	now = now.Add(10 * time.Second)
+	if b.Allow() != nil || b.Allow() != nil {
+		t.Fatalf("As 2 chamadas de teste deveriam passar")
+	}
+	if err := b.Allow(); !errors.Is(err, ErrOpen) {
+		t.Errorf("Chamada além das de teste deveria ser recusada: %v", err)
+	}
+	b.Record(true)
+	b.Record(true)
+	if b.State() != Closed {
+		t.Fatalf("Circuito deveria fechar após os testes, está %s", b.State())
+	}
+
+	snapshot := b.Snapshot()
+	if snapshot.Opens != 2 || snapshot.ShortCircuits != 2 || snapshot.OpenedAt != nil {
+		t.Errorf("Contadores incorretos: %+v", snapshot)
+	}
+}
+
+func TestBreakerTransport(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("teste")
+	registry := NewRegistry(func(string) Config { return Config{FailureThreshold: 2, OpenTimeout: time.Minute} })
+	client := &http.Client{Transport: registry.Get("viacep").Transport(nil)}
+
+	for i := 0; i < 3; i++ {
+		ctx, span := tracer.Start(context.Background(), "call")
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		span.End()
+		if i == 2 && !errors.Is(err, ErrOpen) {
+			t.Errorf("Terceira chamada deveria ser recusada: %v", err)
+		}
+	}
+
+	if calls.Load() != 2 {
+		t.Errorf("Provedor deveria receber só 2 chamadas, recebeu %d", calls.Load())
+	}
+	spans := recorder.Ended()
+	if events := spans[2].Events(); len(events) != 1 || events[0].Name != "circuit-open" {
+		t.Errorf("Chamada recusada deveria gerar o evento circuit-open: %+v", events)
+	}
+	if open := registry.Open(); len(open) != 1 || open[0] != "viacep" {
+		t.Errorf("Circuitos abertos incorretos: %v", open)
+	}
+}
+
+func TestBreakerIsFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// 503 com Retry-After é recusa deliberada, não falha da dependência
+	b := New("service-b", Config{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Minute,
+		IsFailure: func(resp *http.Response, err error) bool {
+			return err != nil || (resp.StatusCode == http.StatusServiceUnavailable && resp.Header.Get("Retry-After") == "")
+		},
+	})
+	client := &http.Client{Transport: b.Transport(nil)}
+	for i := 0; i < 3; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Chamada %d não deveria ser recusada: %v", i+1, err)
+		}
+		resp.Body.Close()
+	}
+	if b.State() != Closed {
+		t.Errorf("Circuito deveria continuar fechado, está %s", b.State())
+	}
+}
@@ -0,0 +1,64 @@
+// Package deadline propaga o orçamento de tempo da requisição entre os serviços:
+// o service-a envia o tempo restante no cabeçalho Header e o service-b o transforma
+// em prazo do contexto, respeitado por todas as chamadas que ele faz.
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Header leva o tempo restante da requisição, em milissegundos. Um valor relativo, e não
+// o horário do prazo, dispensa os relógios dos serviços de estarem sincronizados.
+const Header = "X-Request-Timeout-Ms"
+
+// Inject grava no cabeçalho o tempo restante do contexto, quando ele tem prazo
+func Inject(ctx context.Context, header http.Header) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return
+	}
+	remaining := time.Until(deadline).Milliseconds()
+	if remaining < 1 {
+		remaining = 1
+	}
+	header.Set(Header, strconv.FormatInt(remaining, 10))
+}
+
+// Transport envolve next, gravando no cabeçalho de cada chamada o tempo restante no
+// momento em que ela parte. Abaixo do transport de novas tentativas, cada tentativa
+// informa o que de fato sobra do prazo, e não o que sobrava na primeira.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{next: next}
+}
+
+type transport struct {
+	next http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, ok := req.Context().Deadline(); !ok {
+		return t.next.RoundTrip(req)
+	}
+	// Um RoundTripper não deve alterar a requisição recebida
+	req = req.Clone(req.Context())
+	Inject(req.Context(), req.Header)
+	return t.next.RoundTrip(req)
+}
+
+// FromHeader aplica ao contexto o prazo do cabeçalho. Sem cabeçalho, ou com um valor
+// inválido, o contexto segue sem prazo. Retorna também o orçamento recebido.
+func FromHeader(ctx context.Context, header http.Header) (context.Context, context.CancelFunc, time.Duration) {
+	ms, err := strconv.ParseInt(header.Get(Header), 10, 64)
+	if err != nil || ms <= 0 {
+		return ctx, func() {}, 0
+	}
+	budget := time.Duration(ms) * time.Millisecond
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	return ctx, cancel, budget
+}
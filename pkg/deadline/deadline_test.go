@@ -0,0 +1,97 @@
+package deadline
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"cep-weather-api/pkg/retry"
+)
+
+func TestPropagation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	header := http.Header{}
+	Inject(ctx, header)
+
+	received, cancelReceived, budget := FromHeader(context.Background(), header)
+	defer cancelReceived()
+	if budget <= time.Second || budget > 2*time.Second {
+		t.Errorf("Orçamento incorreto: %s", budget)
+	}
+	if _, ok := received.Deadline(); !ok {
+		t.Errorf("Contexto deveria ter prazo")
+	}
+}
+
+func TestFromHeaderWithoutBudget(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"sem cabeçalho", ""},
+		{"valor inválido", "abc"},
+		{"valor negativo", "-10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.value != "" {
+				header.Set(Header, tt.value)
+			}
+			ctx, cancel, budget := FromHeader(context.Background(), header)
+			defer cancel()
+			if _, ok := ctx.Deadline(); ok || budget != 0 {
+				t.Errorf("Contexto não deveria ter prazo (orçamento %s)", budget)
+			}
+		})
+	}
+
+	ctx := context.Background()
+	header := http.Header{}
+	Inject(ctx, header)
+	if header.Get(Header) != "" {
+		t.Errorf("Contexto sem prazo não deveria gerar cabeçalho")
+	}
+}
+
+func TestTransportRefreshesEachAttempt(t *testing.T) {
+	var received []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ms, _ := strconv.Atoi(r.Header.Get(Header))
+		received = append(received, ms)
+		if len(received) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	// Abaixo das novas tentativas, cada uma informa o tempo que resta quando parte
+	policy := retry.Policy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: 100 * time.Millisecond, RetryOn: []int{http.StatusServiceUnavailable}}
+	client := &http.Client{Transport: policy.Transport("service-b", Transport(nil))}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(received) != 3 {
+		t.Fatalf("Esperadas 3 tentativas, obtidas %d", len(received))
+	}
+	for i := 1; i < len(received); i++ {
+		if received[i] >= received[i-1] || received[i] <= 0 {
+			t.Errorf("Tempo restante deveria diminuir a cada tentativa: %v", received)
+		}
+	}
+	if req.Header.Get(Header) != "" {
+		t.Errorf("A requisição original não deveria ser alterada")
+	}
+}
@@ -0,0 +1,301 @@
+package faults
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Rule descreve uma falha a ser injetada nas chamadas a um provedor
+type Rule struct {
+	ID         string   `json:"id"`
+	Provider   string   `json:"provider"`    // Provedores afetados, separados por vírgula; vazio afeta todos
+	CEPPattern string   `json:"cep_pattern"` // Expressão regular aplicada ao CEP da requisição; vazio afeta todos
+	Percentage *float64 `json:"percentage"`  // Porcentagem das chamadas afetadas; omitido equivale a 100
+	LatencyMs  int      `json:"latency_ms"`  // Atraso antes de responder (ou de seguir para o provedor)
+	Error      string   `json:"error"`       // Simula uma falha de rede com esta mensagem
+	Status     int      `json:"status"`      // Responde com este status HTTP sem chamar o provedor
+	Body       string   `json:"body"`        // Corpo da resposta usada com Status
+	Malformed  bool     `json:"malformed"`   // Responde 200 com um JSON inválido
+}
+
+// Regra com a expressão regular já compilada
+type compiledRule struct {
+	Rule
+	providers []string
+	cep       *regexp.Regexp
+}
+
+// Injector mantém as regras ativas e cria transports HTTP que as aplicam
+type Injector struct {
+	mu    sync.RWMutex
+	rules []compiledRule
+}
+
+// NewInjector cria um Injector sem regras
+func NewInjector() *Injector {
+	return &Injector{}
+}
+
+// FromEnv cria o Injector com as regras base, que o serviço deriva da própria
+// configuração, seguidas das regras do arquivo FAULTS_FILE
+func FromEnv(base ...Rule) (*Injector, error) {
+	rules := append([]Rule(nil), base...)
+	if path := os.Getenv("FAULTS_FILE"); path != "" {
+		loaded, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, loaded...)
+	}
+
+	injector := NewInjector()
+	if err := injector.SetRules(rules); err != nil {
+		return nil, err
+	}
+	return injector, nil
+}
+
+// LoadFile lê uma lista de regras em JSON
+func LoadFile(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("error loading %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// SetRules substitui as regras ativas
+func (i *Injector) SetRules(rules []Rule) error {
+	compiled := make([]compiledRule, 0, len(rules))
+	for n, rule := range rules {
+		if rule.ID == "" {
+			rule.ID = fmt.Sprintf("rule-%d", n+1)
+		}
+
+		c := compiledRule{Rule: rule}
+		for _, provider := range strings.Split(rule.Provider, ",") {
+			if provider = strings.TrimSpace(provider); provider != "" {
+				c.providers = append(c.providers, provider)
+			}
+		}
+		if rule.CEPPattern != "" {
+			re, err := regexp.Compile(rule.CEPPattern)
+			if err != nil {
+				return fmt.Errorf("rule %s: invalid cep_pattern: %w", rule.ID, err)
+			}
+			c.cep = re
+		}
+		compiled = append(compiled, c)
+	}
+
+	i.mu.Lock()
+	i.rules = compiled
+	i.mu.Unlock()
+
+	log.Printf("Injeção de falhas: %d regras ativas", len(compiled))
+	return nil
+}
+
+// Rules retorna as regras ativas
+func (i *Injector) Rules() []Rule {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	rules := make([]Rule, len(i.rules))
+	for n, rule := range i.rules {
+		rules[n] = rule.Rule
+	}
+	return rules
+}
+
+// match retorna a primeira regra que se aplica à chamada, sorteando pela porcentagem
+func (i *Injector) match(provider, cep string) *compiledRule {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	for n := range i.rules {
+		rule := &i.rules[n]
+		if len(rule.providers) > 0 && !contains(rule.providers, provider) {
+			continue
+		}
+		if rule.cep != nil && !rule.cep.MatchString(cep) {
+			continue
+		}
+		if rule.Percentage != nil && rand.Float64()*100 >= *rule.Percentage {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+// Transport envolve next, aplicando as regras às chamadas feitas ao provedor informado
+func (i *Injector) Transport(provider string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{injector: i, provider: provider, next: next}
+}
+
+type transport struct {
+	injector *Injector
+	provider string
+	next     http.RoundTripper
+}
+
+// RoundTrip injeta a falha da regra correspondente, registrando-a no span da chamada
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rule, err := t.injector.apply(req.Context(), t.provider)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return t.next.RoundTrip(req)
+	}
+
+	switch {
+	case rule.Error != "":
+		return nil, fmt.Errorf("injected fault: %s", rule.Error)
+	case rule.Status != 0:
+		return newResponse(req, rule.Status, rule.Body), nil
+	case rule.Malformed:
+		return newResponse(req, http.StatusOK, `{"malformed": `), nil
+	default:
+		// Regra apenas de latência: segue para o provedor
+		return t.next.RoundTrip(req)
+	}
+}
+
+// Check aplica as regras a uma chamada que não passa por HTTP, como a consulta à base
+// offline de CEPs: espera a latência da regra e retorna o status que ela responde (0
+// sem status) ou o erro que ela simula. Com o injetor nil, não faz nada.
+func (i *Injector) Check(ctx context.Context, provider string) (int, error) {
+	if i == nil {
+		return 0, nil
+	}
+	rule, err := i.apply(ctx, provider)
+	if err != nil || rule == nil {
+		return 0, err
+	}
+
+	switch {
+	case rule.Error != "":
+		return 0, fmt.Errorf("injected fault: %s", rule.Error)
+	case rule.Malformed:
+		return 0, fmt.Errorf("injected fault: malformed response")
+	default:
+		return rule.Status, nil
+	}
+}
+
+// apply procura a regra da chamada ao provedor e, havendo uma, registra a falha no span
+// e espera a latência da regra. Retorna nil sem regra e o erro do contexto quando ele
+// termina durante a espera.
+func (i *Injector) apply(ctx context.Context, provider string) (*compiledRule, error) {
+	rule := i.match(provider, CEPFromContext(ctx))
+	if rule == nil {
+		return nil, nil
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Bool("fault.injected", true))
+	span.AddEvent("fault-injected", trace.WithAttributes(
+		attribute.String("fault.rule", rule.ID),
+		attribute.String("fault.provider", provider),
+		attribute.String("fault.type", rule.kind()),
+	))
+	log.Printf("Injetando falha %s (%s) na chamada a %s", rule.ID, rule.kind(), provider)
+
+	if rule.LatencyMs > 0 {
+		select {
+		case <-time.After(time.Duration(rule.LatencyMs) * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	// Uma regra só de latência segue para o provedor, cuja resposta é verdadeira
+	if injected, ok := ctx.Value(injectedKey{}).(*atomic.Bool); ok && rule.kind() != "latency" {
+		injected.Store(true)
+	}
+	return rule, nil
+}
+
+// kind descreve o tipo de falha da regra
+func (r *compiledRule) kind() string {
+	switch {
+	case r.Error != "":
+		return "error"
+	case r.Status != 0:
+		return "status"
+	case r.Malformed:
+		return "malformed"
+	default:
+		return "latency"
+	}
+}
+
+func newResponse(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type cepKey struct{}
+
+type injectedKey struct{}
+
+// TrackInjected retorna um contexto que registra as respostas e os erros injetados nas
+// chamadas feitas com ele, e a função que informa se algum foi injetado. Serve para não
+// guardar em cache, como se fosse verdadeira, uma resposta produzida por uma regra.
+func TrackInjected(ctx context.Context) (context.Context, func() bool) {
+	injected := new(atomic.Bool)
+	return context.WithValue(ctx, injectedKey{}, injected), injected.Load
+}
+
+// WithCEP associa o CEP da requisição ao contexto, para as regras com cep_pattern
+func WithCEP(ctx context.Context, cep string) context.Context {
+	return context.WithValue(ctx, cepKey{}, cep)
+}
+
+// CEPFromContext retorna o CEP associado ao contexto, ou vazio
+func CEPFromContext(ctx context.Context) string {
+	cep, _ := ctx.Value(cepKey{}).(string)
+	return cep
+}
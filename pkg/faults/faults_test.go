@@ -0,0 +1,150 @@
+package faults
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	never := 0.0
+	injector := NewInjector()
+	err := injector.SetRules([]Rule{
+		{ID: "desativada", Provider: "viacep", Percentage: &never, Status: http.StatusInternalServerError},
+		{ID: "cep-sp", Provider: "viacep", CEPPattern: `^01`, Status: http.StatusNotFound},
+		{ID: "rede", Provider: "weatherapi", Error: "connection reset"},
+		{ID: "json", Provider: "opencep", Malformed: true},
+		{ID: "lento", Provider: "brasilapi", LatencyMs: 30},
+	})
+	if err != nil {
+		t.Fatalf("Erro ao configurar regras: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		provider string
+		cep      string
+		status   int
+		body     string
+		err      bool
+		injected bool // Resposta ou erro produzido pela regra, e não pelo provedor
+	}{
+		{"status pelo padrão de CEP", "viacep", "01001000", http.StatusNotFound, "", false, true},
+		{"CEP fora do padrão", "viacep", "29902555", http.StatusOK, `{"ok": true}`, false, false},
+		{"erro de rede", "weatherapi", "", 0, "", true, true},
+		{"JSON inválido", "opencep", "01001000", http.StatusOK, `{"malformed": `, false, true},
+		{"apenas latência", "brasilapi", "01001000", http.StatusOK, `{"ok": true}`, false, false},
+		{"provedor sem regra", "openmeteo", "01001000", http.StatusOK, `{"ok": true}`, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: injector.Transport(tt.provider, nil)}
+			ctx, injected := TrackInjected(WithCEP(context.Background(), tt.cep))
+			req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+
+			resp, err := client.Do(req)
+			if injected() != tt.injected {
+				t.Errorf("Marcação de falha injetada incorreta: obtido %v", injected())
+			}
+			if tt.err {
+				if err == nil || !strings.Contains(err.Error(), "connection reset") {
+					t.Fatalf("Esperado erro injetado, obtido %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			defer resp.Body.Close()
+
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.status || string(body) != tt.body {
+				t.Errorf("Resposta incorreta: status %d, corpo %q", resp.StatusCode, body)
+			}
+		})
+	}
+}
+
+func TestTransportTagsSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	injector := NewInjector()
+	injector.SetRules([]Rule{{ID: "timeout", LatencyMs: 10, Error: "timeout"}})
+
+	ctx, span := tracer.Start(context.Background(), "chamada")
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://provedor.invalid", nil)
+	start := time.Now()
+	if _, err := injector.Transport("viacep", nil).RoundTrip(req); err == nil {
+		t.Fatalf("Esperado erro injetado")
+	}
+	if time.Since(start) < 10*time.Millisecond {
+		t.Errorf("Latência não foi injetada")
+	}
+	span.End()
+
+	ended := recorder.Ended()[0]
+	if len(ended.Events()) != 1 || ended.Events()[0].Name != "fault-injected" {
+		t.Fatalf("Evento de falha ausente: %+v", ended.Events())
+	}
+	attrs := map[string]string{}
+	for _, attr := range ended.Events()[0].Attributes {
+		attrs[string(attr.Key)] = attr.Value.AsString()
+	}
+	if attrs["fault.rule"] != "timeout" || attrs["fault.provider"] != "viacep" || attrs["fault.type"] != "error" {
+		t.Errorf("Atributos incorretos: %v", attrs)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	injector := NewInjector()
+	injector.SetRules([]Rule{
+		{Provider: "offline", CEPPattern: "^0", Status: http.StatusNotFound},
+		{Provider: "offline", CEPPattern: "^1", Error: "dataset unavailable"},
+		{Provider: "offline", CEPPattern: "^2", Malformed: true},
+	})
+
+	tests := []struct {
+		name    string
+		cep     string
+		status  int
+		wantErr bool
+	}{
+		{"status", "01001000", http.StatusNotFound, false},
+		{"erro", "11001000", 0, true},
+		{"malformado", "21001000", 0, true},
+		{"sem regra", "31001000", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := injector.Check(WithCEP(context.Background(), tt.cep), "offline")
+			if status != tt.status || (err != nil) != tt.wantErr {
+				t.Errorf("Resultado incorreto: obtido %d, %v", status, err)
+			}
+		})
+	}
+
+	var none *Injector
+	if status, err := none.Check(context.Background(), "offline"); status != 0 || err != nil {
+		t.Errorf("Injetor nil não deveria injetar falhas: %d, %v", status, err)
+	}
+}
+
+func TestSetRulesInvalidPattern(t *testing.T) {
+	if err := NewInjector().SetRules([]Rule{{CEPPattern: "("}}); err == nil {
+		t.Errorf("Esperado erro para expressão regular inválida")
+	}
+}
@@ -0,0 +1,210 @@
+// Package retry repete chamadas HTTP que falharam de forma transitória, com backoff
+// exponencial e jitter. Métodos idempotentes (GET, HEAD, OPTIONS) são repetidos em
+// falhas de rede e nos status de RetryOn; os demais, só quando a conexão nem chega a
+// ser aberta (erro de dial, como conexão recusada ou DNS), já que um timeout, uma
+// conexão interrompida ou uma resposta indicam que o servidor pode ter processado a
+// chamada.
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Policy define quantas vezes e com que intervalo uma chamada é repetida
+type Policy struct {
+	MaxAttempts int           // Total de tentativas, incluindo a primeira; 1 desativa
+	BaseDelay   time.Duration // Intervalo antes da segunda tentativa, dobrado a cada nova
+	MaxDelay    time.Duration // Limite do intervalo
+	RetryOn     []int         // Status que justificam uma nova tentativa
+
+	// IsPermanent informa se um erro da chamada não justifica nova tentativa (por
+	// exemplo, uma recusa local do limitador de cota); nil repete todos os erros
+	IsPermanent func(err error) bool
+}
+
+// FromEnv lê a política das variáveis <prefix>RETRY_MAX_ATTEMPTS, <prefix>RETRY_BASE_DELAY,
+// <prefix>RETRY_MAX_DELAY e <prefix>RETRY_ON_STATUS (lista separada por vírgulas),
+// usando os valores de def para as ausentes ou inválidas
+func FromEnv(prefix string, def Policy) Policy {
+	policy := def
+	if v, err := strconv.Atoi(os.Getenv(prefix + "RETRY_MAX_ATTEMPTS")); err == nil && v > 0 {
+		policy.MaxAttempts = v
+	}
+	if v, err := time.ParseDuration(os.Getenv(prefix + "RETRY_BASE_DELAY")); err == nil {
+		policy.BaseDelay = v
+	}
+	if v, err := time.ParseDuration(os.Getenv(prefix + "RETRY_MAX_DELAY")); err == nil {
+		policy.MaxDelay = v
+	}
+	if v, ok := os.LookupEnv(prefix + "RETRY_ON_STATUS"); ok {
+		policy.RetryOn = nil
+		for _, field := range strings.Split(v, ",") {
+			if status, err := strconv.Atoi(strings.TrimSpace(field)); err == nil {
+				policy.RetryOn = append(policy.RetryOn, status)
+			}
+		}
+	}
+	return policy
+}
+
+// Transport envolve next, repetindo as chamadas ao destino informado conforme a política
+func (p Policy) Transport(target string, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if p.MaxAttempts <= 1 {
+		return next
+	}
+	return &transport{policy: p, target: target, next: next}
+}
+
+// backoff retorna o intervalo antes da tentativa seguinte à informada: metade fixa e
+// metade aleatória, para que clientes que falharam juntos não voltem juntos
+func (p Policy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay > p.MaxDelay || delay <= 0 {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+func (p Policy) retryableStatus(status int) bool {
+	for _, s := range p.RetryOn {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+type transport struct {
+	policy Policy
+	target string
+	next   http.RoundTripper
+}
+
+// RoundTrip faz a chamada e as novas tentativas, registrando cada uma como evento
+// "upstream-attempt" no span da chamada. Não tenta de novo quando o intervalo não
+// cabe no prazo do contexto.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	span := trace.SpanFromContext(ctx)
+	idempotent := req.Method == http.MethodGet || req.Method == http.MethodHead || req.Method == http.MethodOptions
+
+	// Um corpo que não pode ser relido impede novas tentativas
+	maxAttempts := t.policy.MaxAttempts
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		maxAttempts = 1
+	}
+
+	attemptReq := req
+	for attempt := 1; ; attempt++ {
+		resp, err := t.next.RoundTrip(attemptReq)
+		outcome, retryable := t.classify(ctx, idempotent, resp, err)
+
+		attrs := []attribute.KeyValue{
+			attribute.String("retry.target", t.target),
+			attribute.Int("retry.attempt", attempt),
+			attribute.String("retry.outcome", outcome),
+		}
+		if resp != nil {
+			attrs = append(attrs, attribute.Int("http.status_code", resp.StatusCode))
+		}
+		if err != nil {
+			attrs = append(attrs, attribute.String("error", err.Error()))
+		}
+
+		if !retryable || attempt >= maxAttempts {
+			span.AddEvent("upstream-attempt", trace.WithAttributes(attrs...))
+			return resp, err
+		}
+
+		delay := t.policy.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			attrs = append(attrs, attribute.Bool("retry.deadline_exhausted", true))
+			span.AddEvent("upstream-attempt", trace.WithAttributes(attrs...))
+			return resp, err
+		}
+
+		attrs = append(attrs, attribute.Int64("retry.backoff_ms", delay.Milliseconds()))
+		span.AddEvent("upstream-attempt", trace.WithAttributes(attrs...))
+		log.Printf("Tentativa %d de %d a %s falhou (%s), nova tentativa em %s", attempt, maxAttempts, t.target, outcome, delay.Round(time.Millisecond))
+
+		// A resposta descartada precisa ser lida e fechada para liberar a conexão
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+
+		attemptReq = req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq.Body = body
+		}
+	}
+}
+
+// classify descreve o resultado da tentativa e informa se ele justifica outra
+func (t *transport) classify(ctx context.Context, idempotent bool, resp *http.Response, err error) (string, bool) {
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "deadline-exceeded", false
+		}
+		if ctx.Err() != nil {
+			return "canceled", false
+		}
+		if t.policy.IsPermanent != nil && t.policy.IsPermanent(err) {
+			return "permanent-error", false
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return "timeout", idempotent
+		}
+		return "connection-error", idempotent || isDialError(err)
+	}
+	if resp.StatusCode < 400 {
+		return "success", false
+	}
+	if idempotent && t.policy.retryableStatus(resp.StatusCode) {
+		return "retryable-status", true
+	}
+	return "status", false
+}
+
+// isDialError informa se o erro aconteceu ao abrir a conexão, antes de a requisição
+// ser enviada
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
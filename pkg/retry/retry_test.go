@@ -0,0 +1,177 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+var testPolicy = Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, RetryOn: []int{http.StatusServiceUnavailable}}
+
+// flakyServer responde com os status informados, em ordem, e 200 depois deles
+func flakyServer(t *testing.T, statuses ...int) (*httptest.Server, *atomic.Int64) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		statuses  []int
+		wantCalls int64
+		want      int
+	}{
+		{"GET repetido até o sucesso", http.MethodGet, []int{503, 503}, 3, http.StatusOK},
+		{"GET desiste após o máximo de tentativas", http.MethodGet, []int{503, 503, 503, 503}, 3, http.StatusServiceUnavailable},
+		{"GET não repete status fora da lista", http.MethodGet, []int{500}, 1, http.StatusInternalServerError},
+		{"POST não repete status", http.MethodPost, []int{503}, 1, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, calls := flakyServer(t, tt.statuses...)
+			client := &http.Client{Transport: testPolicy.Transport("teste", nil)}
+
+			req, _ := http.NewRequest(tt.method, server.URL, strings.NewReader("{}"))
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want || calls.Load() != tt.wantCalls {
+				t.Errorf("Obtido status %d com %d chamadas, esperado %d com %d", resp.StatusCode, calls.Load(), tt.want, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryConnectionErrorAndEvents(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("teste")
+
+	// Servidor fechado: a conexão é recusada em todas as tentativas, inclusive no POST
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	ctx, span := tracer.Start(context.Background(), "call")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, strings.NewReader("{}"))
+	_, err := (&http.Client{Transport: testPolicy.Transport("teste", nil)}).Do(req)
+	span.End()
+	if err == nil {
+		t.Fatalf("Esperado erro de conexão")
+	}
+
+	events := recorder.Ended()[0].Events()
+	if len(events) != 3 {
+		t.Fatalf("Esperados 3 eventos de tentativa, obtidos %d", len(events))
+	}
+	for _, event := range events {
+		for _, attr := range event.Attributes {
+			if attr.Key == "retry.outcome" && attr.Value.AsString() != "connection-error" {
+				t.Errorf("Resultado incorreto: %s", attr.Value.AsString())
+			}
+		}
+	}
+}
+
+func TestRetryDroppedConnection(t *testing.T) {
+	tests := []struct {
+		method    string
+		wantCalls int64
+	}{
+		{http.MethodGet, 2},
+		{http.MethodPost, 1}, // O servidor pode ter processado a chamada antes de cair
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			var calls atomic.Int64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					conn, _, _ := w.(http.Hijacker).Hijack()
+					conn.Close()
+					return
+				}
+				w.Write([]byte("ok"))
+			}))
+			defer server.Close()
+
+			req, _ := http.NewRequest(tt.method, server.URL, strings.NewReader("{}"))
+			resp, err := (&http.Client{Transport: testPolicy.Transport("teste", nil)}).Do(req)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if (err == nil) != (tt.wantCalls > 1) || calls.Load() != tt.wantCalls {
+				t.Errorf("Obtido %d chamadas (%v), esperado %d", calls.Load(), err, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// roundTripFunc adapta uma função ao http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestRetryPermanentError(t *testing.T) {
+	errRefused := errors.New("recusa local")
+	var calls atomic.Int64
+	next := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return nil, errRefused
+	})
+
+	policy := testPolicy
+	policy.IsPermanent = func(err error) bool { return errors.Is(err, errRefused) }
+
+	req, _ := http.NewRequest(http.MethodGet, "http://teste", nil)
+	if _, err := policy.Transport("teste", next).RoundTrip(req); !errors.Is(err, errRefused) {
+		t.Fatalf("Erro incorreto: %v", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Erro permanente não deveria gerar nova tentativa: %d chamadas", calls.Load())
+	}
+}
+
+func TestRetryRespectsDeadline(t *testing.T) {
+	server, calls := flakyServer(t, 503, 503)
+	policy := Policy{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Second, RetryOn: []int{503}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := (&http.Client{Transport: policy.Transport("teste", nil)}).Do(req)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	resp.Body.Close()
+	if calls.Load() != 1 || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Intervalo maior que o prazo não deveria gerar nova tentativa: %d chamadas", calls.Load())
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("VIACEP_RETRY_MAX_ATTEMPTS", "5")
+	t.Setenv("VIACEP_RETRY_ON_STATUS", "429, 503")
+
+	policy := FromEnv("VIACEP_", testPolicy)
+	if policy.MaxAttempts != 5 || policy.BaseDelay != testPolicy.BaseDelay || len(policy.RetryOn) != 2 || policy.RetryOn[0] != 429 {
+		t.Errorf("Política incorreta: %+v", policy)
+	}
+}
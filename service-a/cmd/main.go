@@ -4,9 +4,16 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"time"
 
+	"cep-weather-api/pkg/admin"
+	"cep-weather-api/pkg/breaker"
+	"cep-weather-api/pkg/deadline"
+	"cep-weather-api/pkg/faults"
+	"cep-weather-api/pkg/retry"
 	"service-a/internal/client"
 	"service-a/internal/handlers"
+	"service-a/internal/ratelimit"
 )
 
 func main() {
@@ -16,16 +23,45 @@ func main() {
 		serviceBURL = "http://service-b:8082"
 	}
 
-	// Inicializar o cliente de Serviço B
-	serviceBClient := client.NewServiceBClient(serviceBURL)
+	// Carregar regras de injeção de falhas
+	injector, err := faults.FromEnv()
+	if err != nil {
+		log.Fatalf("Erro ao carregar regras de falha: %v", err)
+	}
+
+	// Inicializar o cliente de Serviço B, repetindo as chamadas que falham na conexão e
+	// com um circuito que recusa as chamadas enquanto o Serviço B estiver fora
+	retryPolicy := retry.FromEnv("SERVICE_B_", retry.Policy{MaxAttempts: 3, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second})
+	breakers := breaker.NewRegistry(func(name string) breaker.Config {
+		return breaker.FromEnv("SERVICE_B_", breaker.Config{
+			FailureThreshold: 5,
+			OpenTimeout:      30 * time.Second,
+			HalfOpenProbes:   1,
+			IsFailure:        client.IsServiceBFailure,
+		})
+	})
+	transport := breakers.Get("service-b").Transport(retryPolicy.Transport("service-b", deadline.Transport(injector.Transport("service-b", nil))))
+	serviceBClient := client.NewServiceBClient(serviceBURL, transport, envDuration("SERVICE_B_TIMEOUT", 5*time.Second))
+
+	// Limite de requisições por cliente, por chave de API ou IP; RATE_LIMIT_ENABLED=false desativa
+	var limiter *ratelimit.Limiter
+	if os.Getenv("RATE_LIMIT_ENABLED") != "false" {
+		limitConfig, err := ratelimit.ConfigFromEnv()
+		if err != nil {
+			log.Fatalf("Erro ao carregar limites de taxa: %v", err)
+		}
+		limiter = ratelimit.New(limitConfig)
+	}
 
 	// Inicializar o tracer
 	cleanupFunc := handlers.InitTracer()
 	defer cleanupFunc()
 
 	// Configurar rotas
-	http.HandleFunc("/", handlers.HandleCEPRequest(serviceBClient))
-	http.HandleFunc("/health", handlers.HandleHealthCheck)
+	http.HandleFunc("/", handlers.HandleCEPRequest(serviceBClient, envDuration("REQUEST_TIMEOUT", 10*time.Second), limiter))
+	http.HandleFunc("/health", handlers.HandleHealthCheck(breakers))
+	http.HandleFunc("/metrics", handlers.HandleMetrics(breakers, limiter))
+	http.HandleFunc("/admin/faults", admin.Require(admin.HandleFaults(injector)))
 
 	// Definir porta
 	port := os.Getenv("PORT")
@@ -36,3 +72,17 @@ func main() {
 	log.Printf("Serviço A iniciado na porta %s", port)
 	log.Fatal(http.ListenAndServe(":"+port, nil))
 }
+
+// envDuration lê uma duração (por exemplo, "5s") da variável de ambiente, com valor padrão
+func envDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("Valor inválido para %s (%q), usando %s", key, v, def)
+		return def
+	}
+	return d
+}
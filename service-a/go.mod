@@ -3,10 +3,12 @@ module service-a
 go 1.24
 
 require (
+	cep-weather-api/pkg v0.0.0
 	go.opentelemetry.io/otel v1.35.0
 	go.opentelemetry.io/otel/exporters/zipkin v1.35.0
 	go.opentelemetry.io/otel/sdk v1.35.0
 	go.opentelemetry.io/otel/trace v1.35.0
+	golang.org/x/time v0.11.0
 )
 
 require (
@@ -18,3 +20,6 @@ require (
 	go.opentelemetry.io/otel/metric v1.35.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 )
+
+// Pacotes compartilhados entre os serviços
+replace cep-weather-api/pkg => ../pkg
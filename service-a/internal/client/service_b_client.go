@@ -4,10 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"strings"
+	"time"
 
+	"cep-weather-api/pkg/breaker"
+	"cep-weather-api/pkg/deadline"
 	"service-a/internal/models"
 
 	"go.opentelemetry.io/otel"
@@ -23,17 +29,48 @@ type ServiceBClient struct {
 	tracer  trace.Tracer
 }
 
-// NewServiceBClient cria uma nova instância do cliente do Serviço B
-func NewServiceBClient(baseURL string) *ServiceBClient {
+// NewServiceBClient cria uma nova instância do cliente do Serviço B. O transport
+// informado (por exemplo, o do injetor de falhas) substitui o padrão quando não é nil
+// e deve incluir o deadline.Transport abaixo das novas tentativas, para que o tempo
+// restante chegue ao Serviço B; nil usa o deadline.Transport sobre o padrão. timeout
+// limita cada chamada (0 deixa só o prazo do contexto).
+func NewServiceBClient(baseURL string, transport http.RoundTripper, timeout time.Duration) *ServiceBClient {
+	if transport == nil {
+		transport = deadline.Transport(nil)
+	}
 	return &ServiceBClient{
 		baseURL: baseURL,
-		client:  &http.Client{},
+		client:  &http.Client{Transport: transport, Timeout: timeout},
 		tracer:  otel.GetTracerProvider().Tracer("service-a-client"),
 	}
 }
 
-// SendCEP envia um CEP para o Serviço B e retorna a resposta com temperatura
-func (c *ServiceBClient) SendCEP(ctx context.Context, cep string) (*models.WeatherResponse, int, error) {
+// StatusError é uma resposta de erro do Serviço B, com a mensagem que ele retornou
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("service B returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// IsServiceBFailure classifica, para o circuito do Serviço B, o resultado de uma chamada.
+// Contam como falha os erros de rede e timeouts e o 503 sem Retry-After (Serviço B ou o
+// balanceador à frente dele fora do ar). As demais respostas de erro vêm do próprio
+// Serviço B, que está no ar: falhas dos provedores dele (500, 502), o prazo da
+// requisição esgotado (504) e recusas deliberadas com Retry-After, como a cota de um
+// provedor ou o excesso de carga (503).
+func IsServiceBFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusServiceUnavailable && resp.Header.Get("Retry-After") == ""
+}
+
+// SendCEP envia um CEP para o Serviço B e retorna a resposta com temperatura. A política
+// de degradação, quando informada, segue para o Serviço B no lugar da configurada nele.
+func (c *ServiceBClient) SendCEP(ctx context.Context, cep, degradation string) (*models.WeatherResponse, int, error) {
 	ctx, span := c.tracer.Start(ctx, "call-service-b")
 	defer span.End()
 
@@ -41,7 +78,8 @@ func (c *ServiceBClient) SendCEP(ctx context.Context, cep string) (*models.Weath
 
 	// Preparar a requisição para o Serviço B
 	requestBody := models.ServiceBRequest{
-		CEP: cep,
+		CEP:         cep,
+		Degradation: degradation,
 	}
 
 	reqBody, err := json.Marshal(requestBody)
@@ -59,13 +97,21 @@ func (c *ServiceBClient) SendCEP(ctx context.Context, cep string) (*models.Weath
 
 	req.Header.Set("Content-Type", "application/json")
 
-	// Injetar o contexto de trace no cabeçalho da requisição
+	// Injetar o contexto de trace no cabeçalho; o tempo restante é gravado pelo
+	// deadline.Transport a cada tentativa
 	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	// Enviar a requisição
 	resp, err := c.client.Do(req)
 	if err != nil {
 		span.RecordError(err)
+		if errors.Is(err, breaker.ErrOpen) {
+			return nil, http.StatusServiceUnavailable, fmt.Errorf("service B unavailable: %w", err)
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, http.StatusGatewayTimeout, fmt.Errorf("service B timed out: %w", err)
+		}
 		return nil, http.StatusInternalServerError, fmt.Errorf("error sending request: %w", err)
 	}
 	defer resp.Body.Close()
@@ -81,7 +127,7 @@ func (c *ServiceBClient) SendCEP(ctx context.Context, cep string) (*models.Weath
 
 	// Se o status não for de sucesso, retornar erro
 	if resp.StatusCode != http.StatusOK {
-		return nil, resp.StatusCode, fmt.Errorf("service B returned status %d: %s", resp.StatusCode, string(respBody))
+		return nil, resp.StatusCode, &StatusError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(respBody))}
 	}
 
 	// Decodificar a resposta JSON
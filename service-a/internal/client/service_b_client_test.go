@@ -0,0 +1,203 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cep-weather-api/pkg/breaker"
+	"cep-weather-api/pkg/deadline"
+	"cep-weather-api/pkg/retry"
+)
+
+func TestSendCEPInjectsBudget(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(deadline.Header)
+		w.Write([]byte(`{"city": "São Paulo", "temp_C": 20, "temp_F": 68, "temp_K": 293}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	response, status, err := NewServiceBClient(server.URL, nil, 0).SendCEP(ctx, "01001000", "")
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("Erro inesperado: %d, %v", status, err)
+	}
+	if response.City != "São Paulo" || response.TempC == nil || *response.TempC != 20 {
+		t.Errorf("Resposta incorreta: %+v", response)
+	}
+	if ms, err := strconv.Atoi(received); err != nil || ms <= 1000 || ms > 2000 {
+		t.Errorf("Cabeçalho %s incorreto: %q", deadline.Header, received)
+	}
+}
+
+func TestSendCEPTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(300 * time.Millisecond):
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		timeout time.Duration // Timeout do cliente
+		budget  time.Duration // Prazo do contexto
+	}{
+		{"timeout do cliente", 50 * time.Millisecond, 0},
+		{"prazo da requisição", 0, 50 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.budget > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.budget)
+				defer cancel()
+			}
+
+			_, status, err := NewServiceBClient(server.URL, nil, tt.timeout).SendCEP(ctx, "01001000", "")
+			if err == nil || status != http.StatusGatewayTimeout {
+				t.Errorf("Esperado 504, obtido %d, %v", status, err)
+			}
+		})
+	}
+}
+
+func TestSendCEPRetry(t *testing.T) {
+	policy := retry.Policy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+
+	tests := []struct {
+		name      string
+		fail      func(w http.ResponseWriter) // Resposta da primeira chamada
+		timeout   time.Duration
+		wantCalls int64
+		wantOK    bool
+	}{
+		{"conexão interrompida não é repetida no POST", func(w http.ResponseWriter) {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		}, 0, 1, false},
+		{"status 503 não é repetido no POST", func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}, 0, 1, false},
+		{"timeout não é repetido no POST", func(w http.ResponseWriter) {
+			time.Sleep(200 * time.Millisecond)
+		}, 50 * time.Millisecond, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					tt.fail(w)
+					return
+				}
+				w.Write([]byte(`{"city": "São Paulo", "temp_C": 20}`))
+			}))
+			defer server.Close()
+
+			client := NewServiceBClient(server.URL, policy.Transport("service-b", nil), tt.timeout)
+			_, _, err := client.SendCEP(context.Background(), "01001000", "")
+			if (err == nil) != tt.wantOK {
+				t.Errorf("Resultado incorreto: %v", err)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("Serviço B deveria ser chamado %d vezes, foi %d", tt.wantCalls, got)
+			}
+		})
+	}
+}
+
+func TestIsServiceBFailure(t *testing.T) {
+	response := func(status int, retryAfter string) *http.Response {
+		resp := &http.Response{StatusCode: status, Header: http.Header{}}
+		if retryAfter != "" {
+			resp.Header.Set("Retry-After", retryAfter)
+		}
+		return resp
+	}
+
+	tests := []struct {
+		name string
+		resp *http.Response
+		err  error
+		want bool
+	}{
+		{"erro de rede", nil, errors.New("connection refused"), true},
+		{"503 sem Retry-After", response(http.StatusServiceUnavailable, ""), nil, true},
+		{"503 com Retry-After (cota ou excesso de carga)", response(http.StatusServiceUnavailable, "1"), nil, false},
+		{"500 de falha dos provedores", response(http.StatusInternalServerError, ""), nil, false},
+		{"502 de falha dos provedores de CEP", response(http.StatusBadGateway, ""), nil, false},
+		{"504 de prazo esgotado", response(http.StatusGatewayTimeout, ""), nil, false},
+		{"sucesso", response(http.StatusOK, ""), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsServiceBFailure(tt.resp, tt.err); got != tt.want {
+				t.Errorf("Classificação incorreta: obtido %v, esperado %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendCEPBreaker(t *testing.T) {
+	var calls atomic.Int64
+	var status int
+	var retryAfter string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	newClient := func() (*ServiceBClient, *breaker.Breaker) {
+		b := breaker.New("service-b", breaker.Config{FailureThreshold: 2, OpenTimeout: time.Minute, IsFailure: IsServiceBFailure})
+		return NewServiceBClient(server.URL, b.Transport(nil), 0), b
+	}
+
+	// Respostas de erro do próprio Serviço B e recusas com Retry-After não abrem o circuito
+	responses := []struct {
+		status     int
+		retryAfter string
+	}{
+		{http.StatusInternalServerError, ""},
+		{http.StatusGatewayTimeout, ""},
+		{http.StatusServiceUnavailable, "1"},
+	}
+	for _, r := range responses {
+		status, retryAfter = r.status, r.retryAfter
+		client, b := newClient()
+		for i := 0; i < 3; i++ {
+			client.SendCEP(context.Background(), "01001000", "")
+		}
+		if b.State() != breaker.Closed {
+			t.Errorf("Status %d (Retry-After %q) não deveria abrir o circuito", status, retryAfter)
+		}
+	}
+
+	// Com o Serviço B fora do ar o circuito abre e as chamadas falham com 503 sem chegar a ele
+	status, retryAfter = http.StatusServiceUnavailable, ""
+	client, _ := newClient()
+	calls.Store(0)
+	for i := 0; i < 3; i++ {
+		_, code, err := client.SendCEP(context.Background(), "01001000", "")
+		if i == 2 && (code != http.StatusServiceUnavailable || !errors.Is(err, breaker.ErrOpen)) {
+			t.Errorf("Chamada com o circuito aberto deveria retornar 503: %d, %v", code, err)
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("Serviço B deveria receber só 2 chamadas, recebeu %d", calls.Load())
+	}
+}
@@ -3,14 +3,19 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"os"
 	"regexp"
+	"time"
 
+	"cep-weather-api/pkg/breaker"
+	"cep-weather-api/pkg/faults"
 	"service-a/internal/client"
 	"service-a/internal/models"
+	"service-a/internal/ratelimit"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -72,21 +77,71 @@ func InitTracer() func() {
 	}
 }
 
-// HandleHealthCheck verifica se o serviço está ativo
-func HandleHealthCheck(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"status": "ok",
-	})
+// HandleHealthCheck verifica se o serviço está ativo. Com o circuito do Serviço B aberto
+// o status passa a "degraded", mas a resposta continua 200, já que o processo está no ar.
+func HandleHealthCheck(breakers *breaker.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := "ok"
+		if len(breakers.Open()) > 0 {
+			status = "degraded"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":   status,
+			"breakers": breakers.Snapshots(),
+		})
+	}
+}
+
+// HandleMetrics expõe os contadores do serviço em JSON
+func HandleMetrics(breakers *breaker.Registry, limiter *ratelimit.Limiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		metrics := map[string]interface{}{
+			"breakers": breakers.Snapshots(),
+		}
+		if limiter != nil {
+			metrics["rate_limit"] = limiter.Stats()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(metrics)
+	}
 }
 
-// HandleCEPRequest processa requisições de CEP e encaminha para o Serviço B
-func HandleCEPRequest(serviceBClient *client.ServiceBClient) http.HandlerFunc {
+// HandleCEPRequest processa requisições de CEP e encaminha para o Serviço B. O budget
+// (0 desativa) é o tempo total da requisição, repassado ao Serviço B como prazo. Com
+// limiter (nil desativa), clientes acima do limite da sua classe recebem 429.
+func HandleCEPRequest(serviceBClient *client.ServiceBClient, budget time.Duration, limiter *ratelimit.Limiter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx, span := tracer.Start(r.Context(), "handle-cep-request")
 		defer span.End()
 
+		if limiter != nil {
+			decision := limiter.Allow(r)
+			decision.WriteHeaders(w.Header())
+			span.SetAttributes(
+				attribute.String("ratelimit.client", decision.Client.ID),
+				attribute.String("ratelimit.class", decision.Client.Class),
+				attribute.Int("ratelimit.remaining", decision.Remaining),
+			)
+			if !decision.Allowed {
+				span.AddEvent("rate-limited", trace.WithAttributes(
+					attribute.Int("ratelimit.limit", decision.Limit),
+					attribute.Int64("ratelimit.retry_after_ms", decision.RetryAfter.Milliseconds()),
+				))
+				log.Printf("Requisição de %s (%s) recusada pelo limite de taxa", decision.Client.ID, decision.Client.Class)
+				http.Error(w, "too many requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		if budget > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, budget)
+			defer cancel()
+			span.SetAttributes(attribute.Int64("request.budget_ms", budget.Milliseconds()))
+		}
+
 		// Verificar se é um POST
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -120,8 +175,11 @@ func HandleCEPRequest(serviceBClient *client.ServiceBClient) http.HandlerFunc {
 			return
 		}
 
+		// O CEP acompanha o contexto para as regras de injeção de falhas
+		ctx = faults.WithCEP(ctx, cep)
+
 		// Enviar para o Serviço B
-		weatherResponse, statusCode, err := serviceBClient.SendCEP(ctx, cep)
+		weatherResponse, statusCode, err := serviceBClient.SendCEP(ctx, cep, request.Degradation)
 		if err != nil {
 			if statusCode == http.StatusNotFound {
 				w.WriteHeader(http.StatusNotFound)
@@ -131,6 +189,23 @@ func HandleCEPRequest(serviceBClient *client.ServiceBClient) http.HandlerFunc {
 				w.WriteHeader(http.StatusUnprocessableEntity)
 				w.Write([]byte("invalid zipcode"))
 				return
+			} else if statusCode == http.StatusBadRequest {
+				// O motivo da recusa (por exemplo, a política de degradação) vem do Serviço B
+				message := "invalid request"
+				var statusErr *client.StatusError
+				if errors.As(err, &statusErr) && statusErr.Body != "" {
+					message = statusErr.Body
+				}
+				http.Error(w, message, http.StatusBadRequest)
+				return
+			} else if statusCode == http.StatusServiceUnavailable {
+				log.Printf("Serviço B indisponível: %v", err)
+				http.Error(w, "service B unavailable", http.StatusServiceUnavailable)
+				return
+			} else if statusCode == http.StatusGatewayTimeout {
+				log.Printf("Tempo esgotado ao chamar o Serviço B: %v", err)
+				http.Error(w, "request timed out", http.StatusGatewayTimeout)
+				return
 			} else {
 				log.Printf("Erro ao chamar o Serviço B: %v", err)
 				http.Error(w, "Error calling Service B", http.StatusInternalServerError)
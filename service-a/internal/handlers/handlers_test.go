@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cep-weather-api/pkg/breaker"
+	"service-a/internal/client"
+	"service-a/internal/ratelimit"
+
+	"go.opentelemetry.io/otel"
+)
+
+func init() {
+	// Sem InitTracer, que exporta para o Zipkin, os handlers usam o tracer global (no-op)
+	tracer = otel.GetTracerProvider().Tracer("service-a-handlers")
+}
+
+// postCEP envia o corpo informado ao handler e retorna a resposta gravada
+func postCEP(handler http.HandlerFunc, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestHandleCEPRequestRateLimited(t *testing.T) {
+	var calls atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"city": "São Paulo", "temp_C": 20}`))
+	}))
+	defer server.Close()
+
+	limiter := ratelimit.New(ratelimit.Config{
+		Classes: map[string]ratelimit.Class{ratelimit.Anonymous: {Name: ratelimit.Anonymous, Limit: 1, Window: time.Minute}},
+	})
+	handler := HandleCEPRequest(client.NewServiceBClient(server.URL, nil, 0), 0, limiter)
+
+	if rec := postCEP(handler, `{"cep": "01001000"}`, nil); rec.Code != http.StatusOK || rec.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("Primeira requisição deveria passar: %d %v", rec.Code, rec.Header())
+	}
+
+	// Uma chave desconhecida não dá ao cliente um novo limite
+	rec := postCEP(handler, `{"cep": "01001000"}`, http.Header{ratelimit.APIKeyHeader: {"desconhecida"}})
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("Esperado 429 com Retry-After, obtido %d %v", rec.Code, rec.Header())
+	}
+	if calls.Load() != 1 {
+		t.Errorf("Requisição recusada não deveria chegar ao Serviço B: %d chamadas", calls.Load())
+	}
+}
+
+func TestHandleCEPRequestBudget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(300 * time.Millisecond):
+		}
+	}))
+	defer server.Close()
+
+	handler := HandleCEPRequest(client.NewServiceBClient(server.URL, nil, 0), 50*time.Millisecond, nil)
+	rec := postCEP(handler, `{"cep": "01001000"}`, nil)
+	if rec.Code != http.StatusGatewayTimeout || !strings.Contains(rec.Body.String(), "request timed out") {
+		t.Errorf("Esperado 504, obtido %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandleCEPRequestBreakerOpen(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("Serviço B não deveria ser chamado com o circuito aberto")
+	}))
+	defer server.Close()
+
+	b := breaker.New("service-b", breaker.Config{FailureThreshold: 1, OpenTimeout: time.Minute})
+	b.Allow()
+	b.Record(false)
+
+	handler := HandleCEPRequest(client.NewServiceBClient(server.URL, b.Transport(nil), 0), 0, nil)
+	rec := postCEP(handler, `{"cep": "01001000"}`, nil)
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "service B unavailable") {
+		t.Errorf("Esperado 503, obtido %d %q", rec.Code, rec.Body.String())
+	}
+}
+func TestHandleCEPRequestBadRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string // Corpo da resposta 400 do Serviço B
+		wantBody string
+	}{
+		{"política de degradação inválida", "invalid degradation policy \"always\" (expected none, stale or partial)\n", "invalid degradation policy \"always\" (expected none, stale or partial)\n"},
+		{"formato da requisição", "invalid request format", "invalid request format\n"},
+		{"sem motivo", "", "invalid request\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			handler := HandleCEPRequest(client.NewServiceBClient(server.URL, nil, 0), 0, nil)
+			rec := postCEP(handler, `{"cep": "01001000", "degradation": "always"}`, nil)
+			if rec.Code != http.StatusBadRequest || rec.Body.String() != tt.wantBody {
+				t.Errorf("Esperado 400 %q, obtido %d %q", tt.wantBody, rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -1,19 +1,46 @@
 package models
 
+import "time"
+
 // Request representa a requisição recebida pelo Serviço A
 type CEPRequest struct {
-	CEP string `json:"cep"`
+	CEP         string `json:"cep"`
+	Degradation string `json:"degradation,omitempty"`
 }
 
 // ServiceBRequest representa a requisição enviada para o Serviço B
 type ServiceBRequest struct {
-	CEP string `json:"cep"`
+	CEP         string `json:"cep"`
+	Degradation string `json:"degradation,omitempty"`
 }
 
 // WeatherResponse representa a resposta do Serviço B com os dados de temperatura
 type WeatherResponse struct {
-	City  string  `json:"city"`
-	TempC float64 `json:"temp_C"`
-	TempF float64 `json:"temp_F"`
-	TempK float64 `json:"temp_K"`
+	City               string            `json:"city"`
+	TempC              *float64          `json:"temp_C,omitempty"`
+	TempF              *float64          `json:"temp_F,omitempty"`
+	TempK              *float64          `json:"temp_K,omitempty"`
+	CEPProvider        string            `json:"cep_provider,omitempty"`
+	LocationConfidence string            `json:"location_confidence,omitempty"`
+	Consensus          *WeatherConsensus `json:"consensus,omitempty"`
+	Stale              bool              `json:"stale,omitempty"`
+	ObservedAt         *time.Time        `json:"observed_at,omitempty"`
+	WeatherUnavailable bool              `json:"weather_unavailable,omitempty"`
+}
+
+// WeatherConsensus representa o consenso entre provedores de clima calculado pelo Serviço B
+type WeatherConsensus struct {
+	MedianC  float64           `json:"median_C"`
+	MinC     float64           `json:"min_C"`
+	MaxC     float64           `json:"max_C"`
+	SpreadC  float64           `json:"spread_C"`
+	Readings []ProviderReading `json:"readings"`
+	Failed   []string          `json:"failed,omitempty"`
+}
+
+// ProviderReading representa a leitura de um provedor de clima no consenso
+type ProviderReading struct {
+	Provider           string  `json:"provider"`
+	TempC              float64 `json:"temp_C"`
+	LocationConfidence string  `json:"location_confidence,omitempty"`
 }
@@ -0,0 +1,315 @@
+// Package ratelimit limita as requisições recebidas por cliente. O cliente é a chave
+// de API (cabeçalho X-API-Key), quando ela é conhecida, ou o IP de origem, com o
+// X-Forwarded-For considerado apenas quando a conexão vem de um proxy confiável. Cada
+// classe de cliente tem o seu limite, aplicado com um token bucket por cliente.
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// APIKeyHeader é o cabeçalho com a chave de API do cliente
+const APIKeyHeader = "X-API-Key"
+
+// Classes padrão: clientes sem chave conhecida, identificados pelo IP, e clientes com
+// chave sem classe própria
+const (
+	Anonymous = "anonymous"
+	Key       = "key"
+)
+
+// Class é o limite de uma classe de clientes: Limit requisições por Window, por cliente
+type Class struct {
+	Name   string
+	Limit  int // 0 desativa o limite
+	Window time.Duration
+}
+
+// Config define as classes, as chaves de API conhecidas e os proxies confiáveis
+type Config struct {
+	Classes        map[string]Class
+	APIKeys        map[string]string // Chave de API -> classe; chaves fora da lista são ignoradas
+	TrustedProxies []netip.Prefix
+}
+
+// ConfigFromEnv lê a configuração de RATE_LIMITS (por exemplo,
+// "anonymous=60/m,key=600/m,premium=6000/m"), API_KEYS ("chave=classe,...") e
+// TRUSTED_PROXIES (IPs ou faixas CIDR separados por vírgula)
+func ConfigFromEnv() (Config, error) {
+	config := Config{
+		Classes: map[string]Class{
+			Anonymous: {Name: Anonymous, Limit: 60, Window: time.Minute},
+			Key:       {Name: Key, Limit: 600, Window: time.Minute},
+		},
+		APIKeys: map[string]string{},
+	}
+
+	for _, spec := range splitList(os.Getenv("RATE_LIMITS")) {
+		class, err := parseClass(spec)
+		if err != nil {
+			return config, err
+		}
+		config.Classes[class.Name] = class
+	}
+
+	for _, spec := range splitList(os.Getenv("API_KEYS")) {
+		key, class, _ := strings.Cut(spec, "=")
+		if key == "" {
+			return config, fmt.Errorf("empty API key in %q", spec)
+		}
+		if class == "" {
+			class = Key
+		}
+		if _, ok := config.Classes[class]; !ok {
+			return config, fmt.Errorf("API key with unknown class %q", class)
+		}
+		config.APIKeys[key] = class
+	}
+
+	for _, spec := range splitList(os.Getenv("TRUSTED_PROXIES")) {
+		prefix, err := netip.ParsePrefix(spec)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(spec)
+			if addrErr != nil {
+				return config, fmt.Errorf("invalid trusted proxy %q: %w", spec, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		config.TrustedProxies = append(config.TrustedProxies, prefix.Masked())
+	}
+	return config, nil
+}
+
+// parseClass interpreta "nome=limite/janela", com a janela em s, m ou h
+func parseClass(spec string) (Class, error) {
+	name, limit, ok := strings.Cut(spec, "=")
+	count, unit, ok2 := strings.Cut(limit, "/")
+	n, err := strconv.Atoi(count)
+	windows := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+	window, ok3 := windows[unit]
+	if !ok || !ok2 || !ok3 || err != nil || n < 0 || name == "" {
+		return Class{}, fmt.Errorf("invalid rate limit %q (expected class=limit/s|m|h)", spec)
+	}
+	return Class{Name: name, Limit: n, Window: window}, nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Client identifica quem fez a requisição. Para chaves de API, ID traz um resumo da
+// chave, e não a chave, já que aparece em spans e logs.
+type Client struct {
+	ID    string
+	Class string
+}
+
+// Decision é o resultado da verificação de uma requisição
+type Decision struct {
+	Client     Client
+	Allowed    bool
+	Limit      int
+	Window     time.Duration
+	Remaining  int
+	Reset      time.Duration // Até o limite estar cheio de novo
+	RetryAfter time.Duration // Até a próxima requisição ser aceita, quando recusada
+}
+
+// WriteHeaders grava os cabeçalhos RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset
+// e RateLimit-Policy e, na recusa, Retry-After
+func (d Decision) WriteHeaders(h http.Header) {
+	if d.Limit == 0 {
+		return
+	}
+	h.Set("RateLimit-Limit", strconv.Itoa(d.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(d.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(d.Reset)))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", d.Limit, ceilSeconds(d.Window)))
+	if !d.Allowed {
+		h.Set("Retry-After", strconv.Itoa(ceilSeconds(d.RetryAfter)))
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// Limiter guarda o token bucket de cada cliente
+type Limiter struct {
+	config Config
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	allowed   map[string]uint64
+	rejected  map[string]uint64
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Clientes sem requisições há mais que isso têm o bucket descartado, desde que ele já
+// esteja cheio de novo: descartar um bucket pela metade daria ao cliente um novo, cheio
+const idleBucketTTL = 10 * time.Minute
+
+// ClassStats conta as requisições aceitas e recusadas de uma classe
+type ClassStats struct {
+	Allowed  uint64 `json:"allowed"`
+	Rejected uint64 `json:"rejected"`
+}
+
+// Stats resume o limitador para as métricas
+type Stats struct {
+	Clients int                   `json:"clients"`
+	Classes map[string]ClassStats `json:"classes"`
+}
+
+// New cria o limitador com a configuração informada
+func New(config Config) *Limiter {
+	return &Limiter{
+		config:   config,
+		now:      time.Now,
+		buckets:  make(map[string]*bucket),
+		allowed:  make(map[string]uint64),
+		rejected: make(map[string]uint64),
+	}
+}
+
+// Identify retorna o cliente da requisição e a sua classe. Uma chave fora de APIKeys não
+// identifica o cliente, que é tratado como anônimo: do contrário, bastaria trocar de chave
+// a cada requisição para escapar do limite por IP.
+func (l *Limiter) Identify(r *http.Request) Client {
+	key := r.Header.Get(APIKeyHeader)
+	if class, ok := l.config.APIKeys[key]; ok && key != "" {
+		sum := sha256.Sum256([]byte(key))
+		return Client{ID: "key:" + hex.EncodeToString(sum[:6]), Class: class}
+	}
+	return Client{ID: "ip:" + l.clientIP(r), Class: Anonymous}
+}
+
+// clientIP retorna o IP de origem. Quando a conexão vem de um proxy confiável, percorre o
+// X-Forwarded-For da direita para a esquerda e usa o primeiro endereço não confiável.
+func (l *Limiter) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !l.trusted(addr) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop
+		if !l.trusted(hop) {
+			break
+		}
+	}
+	return addr.Unmap().String()
+}
+
+func (l *Limiter) trusted(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range l.config.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allow verifica a requisição no bucket do seu cliente
+func (l *Limiter) Allow(r *http.Request) Decision {
+	client := l.Identify(r)
+	class, ok := l.config.Classes[client.Class]
+	if !ok {
+		class = l.config.Classes[Key]
+	}
+	decision := Decision{Client: client, Allowed: true, Limit: class.Limit, Window: class.Window}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if class.Limit <= 0 {
+		l.allowed[client.Class]++
+		return decision
+	}
+
+	now := l.now()
+	l.sweep(now)
+	b, ok := l.buckets[client.ID]
+	if !ok {
+		// O bucket começa cheio: até Limit requisições seguidas, repostas ao longo da janela
+		every := rate.Every(class.Window / time.Duration(class.Limit))
+		b = &bucket{limiter: rate.NewLimiter(every, class.Limit)}
+		l.buckets[client.ID] = b
+	}
+	b.lastSeen = now
+
+	decision.Allowed = b.limiter.AllowN(now, 1)
+	tokens := b.limiter.TokensAt(now)
+	decision.Remaining = max(int(math.Floor(tokens)), 0)
+	perToken := class.Window / time.Duration(class.Limit)
+	decision.Reset = time.Duration((float64(class.Limit) - tokens) * float64(perToken))
+	if decision.Allowed {
+		l.allowed[client.Class]++
+	} else {
+		decision.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+		l.rejected[client.Class]++
+	}
+	return decision
+}
+
+// sweep descarta os buckets ociosos e já repostos, no máximo uma vez por minuto; chamada
+// com mu travado. Numa classe por hora, o bucket fica até se encher de novo, mesmo ocioso
+// há mais de idleBucketTTL.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for id, b := range l.buckets {
+		if now.Sub(b.lastSeen) > idleBucketTTL && b.limiter.TokensAt(now) >= float64(b.limiter.Burst()) {
+			delete(l.buckets, id)
+		}
+	}
+}
+
+// Stats retorna o número de clientes acompanhados e as contagens por classe
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := Stats{Clients: len(l.buckets), Classes: make(map[string]ClassStats)}
+	for name := range l.config.Classes {
+		stats.Classes[name] = ClassStats{Allowed: l.allowed[name], Rejected: l.rejected[name]}
+	}
+	return stats
+}
@@ -0,0 +1,227 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestParseClass(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Class
+		wantErr bool
+	}{
+		{"premium=6000/m", Class{Name: "premium", Limit: 6000, Window: time.Minute}, false},
+		{"anonymous=0/s", Class{Name: "anonymous", Limit: 0, Window: time.Second}, false},
+		{"key=100/h", Class{Name: "key", Limit: 100, Window: time.Hour}, false},
+		{"premium=6000", Class{}, true},
+		{"premium=6000/d", Class{}, true},
+		{"=60/m", Class{}, true},
+		{"premium=-1/m", Class{}, true},
+		{"premium=muitas/m", Class{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := parseClass(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Erro incorreto: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Classe incorreta: obtida %+v, esperada %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("RATE_LIMITS", "premium=10/s, anonymous=30/m")
+	t.Setenv("API_KEYS", "abc,def=premium")
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.1, 192.168.0.0/16")
+
+	config, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if config.Classes["premium"].Limit != 10 || config.Classes[Anonymous].Limit != 30 || config.Classes[Key].Limit != 600 {
+		t.Errorf("Classes incorretas: %+v", config.Classes)
+	}
+	if config.APIKeys["abc"] != Key || config.APIKeys["def"] != "premium" {
+		t.Errorf("Chaves incorretas: %+v", config.APIKeys)
+	}
+	if len(config.TrustedProxies) != 2 || config.TrustedProxies[0] != netip.MustParsePrefix("10.0.0.1/32") {
+		t.Errorf("Proxies incorretos: %v", config.TrustedProxies)
+	}
+}
+
+func TestConfigFromEnvErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"limite inválido", map[string]string{"RATE_LIMITS": "premium=10"}},
+		{"chave com classe desconhecida", map[string]string{"API_KEYS": "abc=gold"}},
+		{"chave vazia", map[string]string{"API_KEYS": "=key"}},
+		{"proxy inválido", map[string]string{"TRUSTED_PROXIES": "10.0.0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"RATE_LIMITS", "API_KEYS", "TRUSTED_PROXIES"} {
+				t.Setenv(name, tt.env[name])
+			}
+			if _, err := ConfigFromEnv(); err == nil {
+				t.Errorf("Esperado erro")
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	l := New(Config{TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        string
+		want       string
+	}{
+		{"sem proxy", "203.0.113.5:1234", "", "203.0.113.5"},
+		{"X-Forwarded-For ignorado sem proxy confiável", "203.0.113.5:1234", "198.51.100.7", "203.0.113.5"},
+		{"cliente atrás do proxy", "10.0.0.1:1234", "198.51.100.7", "198.51.100.7"},
+		{"endereço forjado à esquerda ignorado", "10.0.0.1:1234", "6.6.6.6, 198.51.100.7", "198.51.100.7"},
+		{"proxies confiáveis em cadeia", "10.0.0.1:1234", "198.51.100.7, 10.0.0.2", "198.51.100.7"},
+		{"salto inválido para na cadeia", "10.0.0.1:1234", "198.51.100.7, invalido", "10.0.0.1"},
+		{"proxy sem X-Forwarded-For", "10.0.0.1:1234", "", "10.0.0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if got := l.clientIP(req); got != tt.want {
+				t.Errorf("IP incorreto: obtido %s, esperado %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	l := New(Config{APIKeys: map[string]string{"abc": "premium"}})
+
+	tests := []struct {
+		name      string
+		key       string
+		wantClass string
+		wantIP    bool
+	}{
+		{"sem chave", "", Anonymous, true},
+		{"chave conhecida", "abc", "premium", false},
+		{"chave desconhecida é anônima", "xyz", Anonymous, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set(APIKeyHeader, tt.key)
+			client := l.Identify(req)
+			if client.Class != tt.wantClass || (client.ID == "ip:192.0.2.1") != tt.wantIP {
+				t.Errorf("Cliente incorreto: %+v", client)
+			}
+		})
+	}
+}
+
+func TestAllow(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := New(Config{
+		Classes: map[string]Class{Anonymous: {Name: Anonymous, Limit: 2, Window: time.Minute}},
+	})
+	l.now = func() time.Time { return now }
+
+	// Trocar de chave desconhecida a cada requisição não escapa do limite do IP
+	allow := func(key string) Decision {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.Header.Set(APIKeyHeader, key)
+		return l.Allow(req)
+	}
+	for i, key := range []string{"k1", "k2"} {
+		if d := allow(key); !d.Allowed || d.Remaining != 1-i {
+			t.Fatalf("Requisição %d deveria passar: %+v", i+1, d)
+		}
+	}
+	d := allow("k3")
+	if d.Allowed || d.Remaining != 0 || d.RetryAfter != 30*time.Second || d.Reset != time.Minute {
+		t.Fatalf("Requisição além do limite deveria ser recusada: %+v", d)
+	}
+	if stats := l.Stats(); stats.Clients != 1 || stats.Classes[Anonymous] != (ClassStats{Allowed: 2, Rejected: 1}) {
+		t.Errorf("Estatísticas incorretas: %+v", stats)
+	}
+
+	header := http.Header{}
+	d.WriteHeaders(header)
+	want := map[string]string{
+		"RateLimit-Limit":     "2",
+		"RateLimit-Remaining": "0",
+		"RateLimit-Reset":     "60",
+		"RateLimit-Policy":    "2;w=60",
+		"Retry-After":         "30",
+	}
+	for name, value := range want {
+		if got := header.Get(name); got != value {
+			t.Errorf("Cabeçalho %s incorreto: obtido %q, esperado %q", name, got, value)
+		}
+	}
+
+	// Passado o intervalo de um token, o cliente volta a ser aceito
+	now = now.Add(30 * time.Second)
+	if d := allow(""); !d.Allowed || d.Remaining != 0 {
+		t.Errorf("Requisição deveria passar após a reposição: %+v", d)
+	}
+}
+
+func TestSweepKeepsBucketsUntilRefilled(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := New(Config{
+		Classes: map[string]Class{Anonymous: {Name: Anonymous, Limit: 2, Window: time.Hour}},
+	})
+	l.now = func() time.Time { return now }
+
+	allow := func(remoteAddr string) Decision {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.RemoteAddr = remoteAddr
+		return l.Allow(req)
+	}
+	allow("203.0.113.5:1234")
+	allow("203.0.113.5:1234")
+
+	// Ocioso há mais de idleBucketTTL, mas com o bucket ainda vazio: o cliente continua
+	// limitado em vez de ganhar um bucket novo, cheio
+	now = now.Add(idleBucketTTL + time.Minute)
+	if d := allow("203.0.113.5:1234"); d.Allowed {
+		t.Fatalf("Bucket descartado antes de se encher de novo: %+v", d)
+	}
+
+	// Reposto o bucket (uma janela sem requisições), ele é descartado
+	now = now.Add(time.Hour)
+	allow("198.51.100.7:1234")
+	if stats := l.Stats(); stats.Clients != 1 {
+		t.Errorf("Bucket cheio e ocioso deveria ser descartado: %d clientes", stats.Clients)
+	}
+}
+
+func TestWriteHeadersWithoutLimit(t *testing.T) {
+	header := http.Header{}
+	Decision{Allowed: true}.WriteHeaders(header)
+	if len(header) != 0 {
+		t.Errorf("Sem limite não deveria haver cabeçalhos: %v", header)
+	}
+
+	header = http.Header{}
+	Decision{Allowed: true, Limit: 5, Remaining: 4, Reset: 1500 * time.Millisecond, Window: time.Second}.WriteHeaders(header)
+	if header.Get("Retry-After") != "" || header.Get("RateLimit-Reset") != "2" {
+		t.Errorf("Cabeçalhos incorretos: %v", header)
+	}
+}
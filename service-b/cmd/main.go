@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"sync/atomic"
+	"time"
 
+	"cep-weather-api/pkg/admin"
+	"cep-weather-api/pkg/faults"
+	"service-b/internal/concurrency"
 	"service-b/internal/handlers"
 	"service-b/internal/services"
 )
@@ -14,12 +20,54 @@ func main() {
 	cleanupFunc := handlers.InitTracer()
 	defer cleanupFunc()
 
+	// Carregar regras de injeção de falhas
+	injector, err := faults.FromEnv(services.LegacyFaultRules()...)
+	if err != nil {
+		log.Fatalf("Erro ao carregar regras de falha: %v", err)
+	}
+
 	// Inicializar serviços
-	weatherService := services.NewWeatherService()
+	weatherService, err := services.NewWeatherService(injector)
+	if err != nil {
+		log.Fatalf("Erro ao inicializar serviço de clima: %v", err)
+	}
+
+	// Aquecer os caches em segundo plano; /ready responde 503 até o fim do aquecimento
+	var ready atomic.Bool
+	go func() {
+		if err := weatherService.WarmUpFromEnv(context.Background()); err != nil {
+			log.Printf("Erro no aquecimento dos caches: %v", err)
+		}
+		ready.Store(true)
+	}()
+
+	// Política de degradação padrão, quando os provedores de clima falham
+	degradation, err := handlers.ParseDegradation(os.Getenv("DEGRADATION_POLICY"))
+	if err != nil {
+		log.Fatalf("Erro ao carregar a política de degradação: %v", err)
+	}
+
+	// Limite adaptativo de requisições simultâneas; CONCURRENCY_MAX_LIMIT=0 desativa
+	var limiter *concurrency.Limiter
+	limitConfig := concurrency.FromEnv("CONCURRENCY_", concurrency.Config{
+		InitialLimit:  20,
+		MinLimit:      4,
+		MaxLimit:      200,
+		LatencyTarget: 2 * time.Second,
+		Backoff:       0.9,
+	})
+	if limitConfig.MaxLimit > 0 {
+		limiter = concurrency.New(limitConfig)
+	}
 
 	// Configurar rotas
-	http.HandleFunc("/", handlers.HandleWeatherRequest(weatherService))
-	http.HandleFunc("/health", handlers.HandleHealthCheck)
+	http.HandleFunc("/", handlers.LimitConcurrency(limiter, handlers.HandleWeatherRequest(weatherService, degradation)))
+	http.HandleFunc("/health", handlers.HandleHealthCheck(weatherService))
+	http.HandleFunc("/ready", handlers.HandleReadiness(&ready))
+	http.HandleFunc("/metrics", handlers.HandleMetrics(weatherService, limiter))
+	http.HandleFunc("/admin/faults", admin.Require(admin.HandleFaults(injector)))
+	http.HandleFunc("/admin/cache", admin.Require(handlers.HandleCacheAdmin(weatherService)))
+	http.HandleFunc("/admin/cache/", admin.Require(handlers.HandleCacheAdmin(weatherService)))
 
 	// Configurar porta
 	port := os.Getenv("PORT")
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Backend armazena valores já serializados, com validade por chave
+type Backend interface {
+	// Name identifica o backend em logs e spans
+	Name() string
+	// Get retorna o valor da chave; ok é false quando ela não existe ou expirou
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set grava o valor com a validade informada
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete remove a chave
+	Delete(ctx context.Context, key string) error
+	// Keys lista as chaves que começam com o prefixo
+	Keys(ctx context.Context, prefix string) ([]string, error)
+}
+
+// Codec serializa os valores guardados no backend
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// NewCodec cria o codec correspondente ao formato informado: json (padrão) ou gob
+func NewCodec(format string) (Codec, error) {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", "json":
+		return JSONCodec{}, nil
+	case "gob":
+		return GobCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unknown cache format: %s", format)
+	}
+}
+
+// JSONCodec serializa em JSON, legível ao inspecionar o Redis
+type JSONCodec struct{}
+
+func (JSONCodec) Name() string { return "json" }
+
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// GobCodec serializa em gob, mais compacto e rápido que JSON
+type GobCodec struct{}
+
+func (GobCodec) Name() string { return "gob" }
+
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
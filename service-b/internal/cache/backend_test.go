@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+type testEntry struct {
+	City string    `json:"city"`
+	At   time.Time `json:"at"`
+}
+
+func TestStoreBackends(t *testing.T) {
+	server := miniredis.RunT(t)
+	redis, err := NewRedis("redis://" + server.Addr())
+	if err != nil {
+		t.Fatalf("Erro ao conectar ao Redis: %v", err)
+	}
+	defer redis.Close()
+
+	backends := map[string]Backend{
+		"memory": NewMemory(10),
+		"redis":  redis,
+		"disk":   newTestDisk(t, 10),
+	}
+	for name, backend := range backends {
+		for _, codec := range []Codec{JSONCodec{}, GobCodec{}} {
+			t.Run(name+"/"+codec.Name(), func(t *testing.T) {
+				ctx := context.Background()
+				store := NewStore[testEntry]("cep", backend, "teste:"+codec.Name()+":", codec)
+
+				if _, ok := store.Get(ctx, "01001000"); ok {
+					t.Fatalf("Cache deveria começar vazio")
+				}
+
+				want := testEntry{City: "São Paulo", At: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)}
+				store.Set(ctx, "01001000", want, time.Minute)
+				got, ok := store.Get(ctx, "01001000")
+				if !ok || got.City != want.City || !got.At.Equal(want.At) {
+					t.Errorf("Valor incorreto: obtido %+v, esperado %+v", got, want)
+				}
+
+				store.Delete(ctx, "01001000")
+				if _, ok := store.Get(ctx, "01001000"); ok {
+					t.Errorf("Chave removida não deveria ser encontrada")
+				}
+
+				stats := store.Stats()
+				if stats.Hits != 1 || stats.Misses != 2 || stats.Errors != 0 {
+					t.Errorf("Contadores incorretos: %+v", stats)
+				}
+			})
+		}
+	}
+}
+
+func TestRedisKeyPrefixAndTTL(t *testing.T) {
+	server := miniredis.RunT(t)
+	redis, err := NewRedis("redis://" + server.Addr())
+	if err != nil {
+		t.Fatalf("Erro ao conectar ao Redis: %v", err)
+	}
+	defer redis.Close()
+
+	ctx := context.Background()
+	store := NewStore[testEntry]("cep", redis, "cep-weather:", JSONCodec{})
+	store.Set(ctx, "01001000", testEntry{City: "São Paulo"}, time.Minute)
+
+	if !server.Exists("cep-weather:cep:01001000") {
+		t.Fatalf("Chave deveria ser gravada com o prefixo: %v", server.Keys())
+	}
+	if ttl := server.TTL("cep-weather:cep:01001000"); ttl != time.Minute {
+		t.Errorf("TTL incorreto: %s", ttl)
+	}
+
+	server.FastForward(2 * time.Minute)
+	if _, ok := store.Get(ctx, "01001000"); ok {
+		t.Errorf("Chave expirada não deveria ser encontrada")
+	}
+}
+
+func TestStoreBackendFailure(t *testing.T) {
+	server := miniredis.RunT(t)
+	redis, err := NewRedis("redis://" + server.Addr())
+	if err != nil {
+		t.Fatalf("Erro ao conectar ao Redis: %v", err)
+	}
+	defer redis.Close()
+	server.Close()
+
+	ctx := context.Background()
+	store := NewStore[testEntry]("cep", redis, "", JSONCodec{})
+	store.Set(ctx, "01001000", testEntry{City: "São Paulo"}, time.Minute)
+	if _, ok := store.Get(ctx, "01001000"); ok {
+		t.Errorf("Falha do Redis deveria ser tratada como ausência")
+	}
+	if stats := store.Stats(); stats.Errors != 2 || stats.Misses != 1 {
+		t.Errorf("Contadores incorretos: %+v", stats)
+	}
+}
+
+func TestRedisIncrBy(t *testing.T) {
+	server := miniredis.RunT(t)
+	redis, err := NewRedis("redis://" + server.Addr())
+	if err != nil {
+		t.Fatalf("Erro ao conectar ao Redis: %v", err)
+	}
+	defer redis.Close()
+
+	ctx := context.Background()
+	expireAt := time.Now().Add(time.Hour)
+	for i, n := range []int64{1, 1, -1} {
+		if _, err := redis.IncrBy(ctx, "quota:weatherapi", n, expireAt); err != nil {
+			t.Fatalf("Erro na soma %d: %v", i+1, err)
+		}
+	}
+	if got, err := redis.IncrBy(ctx, "quota:weatherapi", 1, expireAt); err != nil || got != 2 {
+		t.Errorf("Contador incorreto: obtido %d (%v), esperado 2", got, err)
+	}
+	if ttl := server.TTL("quota:weatherapi"); ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Errorf("Contador deveria vencer em expireAt: %s", ttl)
+	}
+}
+
+func TestNewCodec(t *testing.T) {
+	for _, format := range []string{"", "json", "GOB"} {
+		if _, err := NewCodec(format); err != nil {
+			t.Errorf("Erro inesperado para %q: %v", format, err)
+		}
+	}
+	if _, err := NewCodec("xml"); err == nil {
+		t.Errorf("Esperado erro para formato desconhecido")
+	}
+}
+
+func TestStoreAdministration(t *testing.T) {
+	server := miniredis.RunT(t)
+	redis, err := NewRedis("redis://" + server.Addr())
+	if err != nil {
+		t.Fatalf("Erro ao conectar ao Redis: %v", err)
+	}
+	defer redis.Close()
+
+	for name, backend := range map[string]Backend{"memory": NewMemory(10), "redis": redis, "disk": newTestDisk(t, 10)} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			store := NewStore[testEntry]("cep", backend, "admin:", JSONCodec{})
+
+			// Entradas gravadas há 2 horas, há 5 minutos e agora
+			store.now = func() time.Time { return now.Add(-2 * time.Hour) }
+			store.Set(ctx, "01001000", testEntry{City: "São Paulo"}, 24*time.Hour)
+			store.now = func() time.Time { return now.Add(-5 * time.Minute) }
+			store.Set(ctx, "01310100", testEntry{City: "São Paulo"}, 24*time.Hour)
+			store.now = func() time.Time { return now }
+			store.Set(ctx, "29902555", testEntry{City: "Linhares"}, 24*time.Hour)
+			store.Get(ctx, "01001000")
+			store.Get(ctx, "99999999")
+
+			entry, ok, err := store.Inspect(ctx, "29902555")
+			if err != nil || !ok || entry.Value.(testEntry).City != "Linhares" || entry.Cache != "cep" {
+				t.Fatalf("Inspeção incorreta: %+v %v %v", entry, ok, err)
+			}
+
+			report, err := store.Report(ctx)
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if report.Size != 3 || report.HitRatio != 0.5 {
+				t.Errorf("Relatório incorreto: %+v", report)
+			}
+			if report.Ages["<1m"] != 1 || report.Ages["1m-10m"] != 1 || report.Ages["1h-24h"] != 1 {
+				t.Errorf("Distribuição de idades incorreta: %v", report.Ages)
+			}
+
+			if removed, err := store.PurgePrefix(ctx, "01"); err != nil || removed != 2 {
+				t.Errorf("Remoção por prefixo incorreta: %d %v", removed, err)
+			}
+			if ok, err := store.Remove(ctx, "29902555"); err != nil || !ok {
+				t.Errorf("Remoção da chave incorreta: %v %v", ok, err)
+			}
+			if entries, _ := store.Entries(ctx); len(entries) != 0 {
+				t.Errorf("Cache deveria estar vazio: %+v", entries)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Disk é o backend persistido em um arquivo bbolt, para valores que devem sobreviver
+// a reinícios. Guarda no máximo capacity chaves: ao passar do limite, remove as
+// vencidas e, se ainda for preciso, as que venceriam primeiro. Compact remove as
+// vencidas e reescreve o arquivo, devolvendo ao disco o espaço liberado.
+type Disk struct {
+	path     string
+	capacity int
+	now      func() time.Time
+
+	mu sync.RWMutex // Protege db enquanto Compact reescreve o arquivo
+	db *bolt.DB
+
+	size        atomic.Int64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+}
+
+// Bucket com as chaves; cada valor começa com o vencimento (UnixNano, 8 bytes, 0 sem vencimento)
+var diskBucket = []byte("cache")
+
+// Ao passar da capacidade, remove chaves até sobrar esta fração dela, para não
+// percorrer o arquivo a cada gravação
+const diskEvictionTarget = 0.9
+
+// NewDisk abre (ou cria) o arquivo do backend em disco com a capacidade informada
+func NewDisk(path string, capacity int) (*Disk, error) {
+	d := &Disk{path: path, capacity: capacity, now: time.Now}
+	if err := d.open(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *Disk) open() error {
+	// O arquivo fica travado enquanto aberto; outro processo desiste depois do timeout
+	db, err := bolt.Open(d.path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(diskBucket)
+		if err != nil {
+			return err
+		}
+		d.size.Store(int64(bucket.Stats().KeyN))
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return err
+	}
+	d.db = db
+	return nil
+}
+
+// Name retorna o nome do backend
+func (d *Disk) Name() string {
+	return "disk"
+}
+
+// Get retorna o valor da chave, se ele existir e não tiver expirado
+func (d *Disk) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var value []byte
+	var ok bool
+	err := d.db.View(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(diskBucket).Get([]byte(key))
+		if raw == nil || d.expired(raw) {
+			return nil
+		}
+		value, ok = append([]byte{}, raw[8:]...), true
+		return nil
+	})
+	return value, ok, err
+}
+
+// Set grava o valor com a validade informada, removendo chaves se a capacidade estourar
+func (d *Disk) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	raw := make([]byte, 8+len(value))
+	if ttl > 0 {
+		binary.BigEndian.PutUint64(raw, uint64(d.now().Add(ttl).UnixNano()))
+	}
+	copy(raw[8:], value)
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var evicted, expired int
+	err := d.update(func(bucket *bolt.Bucket) (int, error) {
+		added := 0
+		if bucket.Get([]byte(key)) == nil {
+			added = 1
+		}
+		if err := bucket.Put([]byte(key), raw); err != nil {
+			return 0, err
+		}
+		evicted, expired = 0, 0
+		if size := d.size.Load() + int64(added); d.capacity > 0 && size > int64(d.capacity) {
+			var err error
+			evicted, expired, err = d.evict(bucket, int(size)-int(float64(d.capacity)*diskEvictionTarget), key)
+			if err != nil {
+				return 0, err
+			}
+		}
+		return added - evicted - expired, nil
+	})
+	if err != nil {
+		return err
+	}
+	d.evictions.Add(uint64(evicted))
+	d.expirations.Add(uint64(expired))
+	return nil
+}
+
+// update executa fn em uma transação de escrita e soma ao tamanho a variação de chaves
+// que fn retorna ainda dentro da transação: como o bbolt tem um único escritor por vez,
+// a transação seguinte já encontra o tamanho atualizado. Se a gravação falhar depois
+// de fn, a variação é desfeita.
+func (d *Disk) update(fn func(bucket *bolt.Bucket) (int, error)) error {
+	delta := 0
+	err := d.db.Update(func(tx *bolt.Tx) error {
+		n, err := fn(tx.Bucket(diskBucket))
+		if err != nil {
+			return err
+		}
+		delta = n
+		d.size.Add(int64(n))
+		return nil
+	})
+	if err != nil && delta != 0 {
+		d.size.Add(-int64(delta))
+	}
+	return err
+}
+
+// evict remove pelo menos n chaves, primeiro as vencidas e depois as que venceriam
+// primeiro, preservando a chave recém-gravada
+func (d *Disk) evict(bucket *bolt.Bucket, n int, keep string) (evicted, expired int, err error) {
+	type candidate struct {
+		key       []byte
+		expiresAt uint64
+	}
+	var candidates []candidate
+	var stale [][]byte
+	err = bucket.ForEach(func(k, v []byte) error {
+		switch {
+		case string(k) == keep:
+		case d.expired(v):
+			stale = append(stale, append([]byte{}, k...))
+		default:
+			candidates = append(candidates, candidate{append([]byte{}, k...), binary.BigEndian.Uint64(v)})
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, k := range stale {
+		if err := bucket.Delete(k); err != nil {
+			return evicted, expired, err
+		}
+		expired++
+	}
+
+	// Sem vencimento (0) conta como o mais distante
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i].expiresAt-1, candidates[j].expiresAt-1
+		return a < b
+	})
+	for _, c := range candidates {
+		if evicted+expired >= n {
+			break
+		}
+		if err := bucket.Delete(c.key); err != nil {
+			return evicted, expired, err
+		}
+		evicted++
+	}
+	return evicted, expired, nil
+}
+
+// Delete remove a chave
+func (d *Disk) Delete(ctx context.Context, key string) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.update(func(bucket *bolt.Bucket) (int, error) {
+		if bucket.Get([]byte(key)) == nil {
+			return 0, nil
+		}
+		return -1, bucket.Delete([]byte(key))
+	})
+}
+
+// Keys lista as chaves não vencidas que começam com o prefixo
+func (d *Disk) Keys(ctx context.Context, prefix string) ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var keys []string
+	err := d.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(diskBucket).Cursor()
+		for k, v := cursor.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, v = cursor.Next() {
+			if !d.expired(v) {
+				keys = append(keys, string(k))
+			}
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// Compact remove as chaves vencidas e reescreve o arquivo sem o espaço livre que
+// elas deixaram. Retorna quantas chaves saíram.
+func (d *Disk) Compact(ctx context.Context) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var stale [][]byte
+	err := d.update(func(bucket *bolt.Bucket) (int, error) {
+		stale = nil
+		err := bucket.ForEach(func(k, v []byte) error {
+			if d.expired(v) {
+				stale = append(stale, append([]byte{}, k...))
+			}
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, k := range stale {
+			if err := bucket.Delete(k); err != nil {
+				return 0, err
+			}
+		}
+		return -len(stale), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	d.expirations.Add(uint64(len(stale)))
+
+	// Copia as chaves para um arquivo novo e troca os arquivos; em caso de falha,
+	// o arquivo original continua em uso
+	tmp := d.path + ".compact"
+	os.Remove(tmp)
+	dst, err := bolt.Open(tmp, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return len(stale), err
+	}
+	if err := bolt.Compact(dst, d.db, 0); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return len(stale), err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return len(stale), err
+	}
+	if err := d.db.Close(); err != nil {
+		os.Remove(tmp)
+		return len(stale), err
+	}
+	if err := os.Rename(tmp, d.path); err != nil {
+		os.Remove(tmp)
+		return len(stale), errors.Join(err, d.open())
+	}
+	return len(stale), d.open()
+}
+
+// RunCompaction chama Compact a cada intervalo até o contexto terminar
+func (d *Disk) RunCompaction(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			removed, err := d.Compact(ctx)
+			if err != nil {
+				log.Printf("Erro na compactação do cache em disco %s: %v", d.path, err)
+				continue
+			}
+			log.Printf("Cache em disco %s compactado em %s: %d chaves vencidas removidas, %d restantes", d.path, time.Since(start).Round(time.Millisecond), removed, d.size.Load())
+		}
+	}
+}
+
+// Close fecha o arquivo
+func (d *Disk) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.db.Close()
+}
+
+// Stats retorna o tamanho e as remoções do cache; acertos e falhas são contados pelo Store
+func (d *Disk) Stats() Stats {
+	return Stats{
+		Size:        int(d.size.Load()),
+		Capacity:    d.capacity,
+		Evictions:   d.evictions.Load(),
+		Expirations: d.expirations.Load(),
+	}
+}
+
+// expired informa se o valor gravado (com o vencimento no início) já venceu
+func (d *Disk) expired(raw []byte) bool {
+	if len(raw) < 8 {
+		return true
+	}
+	expiresAt := binary.BigEndian.Uint64(raw)
+	return expiresAt != 0 && uint64(d.now().UnixNano()) >= expiresAt
+}
@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestDisk abre um backend em disco em um diretório temporário do teste
+func newTestDisk(t *testing.T, capacity int) *Disk {
+	t.Helper()
+	disk, err := NewDisk(filepath.Join(t.TempDir(), "cache.db"), capacity)
+	if err != nil {
+		t.Fatalf("Erro ao abrir o cache em disco: %v", err)
+	}
+	t.Cleanup(func() { disk.Close() })
+	return disk
+}
+
+func TestDiskPersistence(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	disk, err := NewDisk(path, 10)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	store := NewStore[testEntry]("cep", disk, "", JSONCodec{})
+	store.Set(ctx, "01001000", testEntry{City: "São Paulo"}, time.Hour)
+	disk.Close()
+
+	disk, err = NewDisk(path, 10)
+	if err != nil {
+		t.Fatalf("Erro ao reabrir: %v", err)
+	}
+	defer disk.Close()
+	store = NewStore[testEntry]("cep", disk, "", JSONCodec{})
+	if got, ok := store.Get(ctx, "01001000"); !ok || got.City != "São Paulo" {
+		t.Errorf("Entrada deveria sobreviver ao reinício: %+v %v", got, ok)
+	}
+	if size := disk.Stats().Size; size != 1 {
+		t.Errorf("Tamanho incorreto após reabrir: %d", size)
+	}
+}
+
+func TestDiskCapacityAndCompaction(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	disk := newTestDisk(t, 10)
+	disk.now = func() time.Time { return now }
+
+	// Duas chaves que vencem logo e dez que vencem em ordem: a 11ª e a 12ª gravações
+	// passam da capacidade e removem as vencidas e as que venceriam primeiro
+	disk.Set(ctx, "curta:1", []byte("x"), time.Second)
+	disk.Set(ctx, "curta:2", []byte("x"), time.Second)
+	now = now.Add(2 * time.Second)
+	for i := 0; i < 10; i++ {
+		disk.Set(ctx, "cep:"+string(rune('a'+i)), []byte("x"), time.Duration(i+1)*time.Hour)
+	}
+
+	stats := disk.Stats()
+	if stats.Size > 10 || stats.Expirations != 2 {
+		t.Fatalf("Capacidade não respeitada: %+v", stats)
+	}
+	if _, ok, _ := disk.Get(ctx, "cep:j"); !ok {
+		t.Errorf("A chave mais recente deveria continuar no cache")
+	}
+
+	// Compact remove as vencidas e preserva as demais
+	now = now.Add(3 * time.Hour)
+	before := disk.Stats().Size
+	removed, err := disk.Compact(ctx)
+	if err != nil {
+		t.Fatalf("Erro na compactação: %v", err)
+	}
+	keys, err := disk.Keys(ctx, "cep:")
+	if err != nil || len(keys) != before-removed || disk.Stats().Size != len(keys) {
+		t.Errorf("Compactação incorreta: %d removidas de %d, restam %v (%v)", removed, before, keys, err)
+	}
+	if _, ok, _ := disk.Get(ctx, "cep:j"); !ok {
+		t.Errorf("Chave válida deveria sobreviver à compactação")
+	}
+}
+
+func TestDiskConcurrentSetKeepsSize(t *testing.T) {
+	ctx := context.Background()
+	disk := newTestDisk(t, 20)
+
+	// Gravações simultâneas veem o tamanho deixado pela anterior e respeitam a capacidade
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				key := fmt.Sprintf("cep:%d:%d", g, i)
+				disk.Set(ctx, key, []byte("x"), time.Hour)
+				if i%5 == 0 {
+					disk.Delete(ctx, key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	keys, err := disk.Keys(ctx, "")
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if size := disk.Stats().Size; size != len(keys) || size > 20 {
+		t.Errorf("Tamanho incorreto: contador %d, chaves %d, capacidade 20", size, len(keys))
+	}
+}
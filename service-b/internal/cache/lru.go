@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats reúne os contadores de uso do cache
+type Stats struct {
+	Size        int    `json:"size"`
+	Capacity    int    `json:"capacity"`
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`   // Entradas removidas por falta de espaço
+	Expirations uint64 `json:"expirations"` // Entradas removidas por TTL vencido
+	Errors      uint64 `json:"errors"`      // Falhas de leitura ou gravação no backend
+}
+
+// LRU é um cache em memória com TTL por entrada e capacidade máxima. Quando cheio,
+// remove a entrada usada há mais tempo.
+type LRU[V any] struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	items    map[string]*list.Element
+	order    *list.List // Da mais recente para a mais antiga
+	now      func() time.Time
+
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+}
+
+type entry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+// NewLRU cria um cache com a capacidade e o TTL padrão informados
+func NewLRU[V any](capacity int, ttl time.Duration) *LRU[V] {
+	return &LRU[V]{
+		capacity: capacity,
+		ttl:      ttl,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get retorna o valor da chave se ele existir e não tiver expirado
+func (c *LRU[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return zero, false
+	}
+
+	e := elem.Value.(*entry[V])
+	if !c.now().Before(e.expires) {
+		c.remove(elem)
+		c.expirations.Add(1)
+		c.misses.Add(1)
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+	c.hits.Add(1)
+	return e.value, true
+}
+
+// Set grava o valor com o TTL padrão do cache
+func (c *LRU[V]) Set(key string, value V) {
+	c.SetWithTTL(key, value, c.ttl)
+}
+
+// SetWithTTL grava o valor com um TTL específico
+func (c *LRU[V]) SetWithTTL(key string, value V, ttl time.Duration) {
+	if c.capacity <= 0 || ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(ttl)
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*entry[V])
+		e.value = value
+		e.expires = expires
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&entry[V]{key: key, value: value, expires: expires})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+		c.evictions.Add(1)
+	}
+}
+
+// Delete remove a chave do cache
+func (c *LRU[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// Keys retorna as chaves que não expiraram, da mais recente para a mais antiga
+func (c *LRU[V]) Keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	keys := make([]string, 0, c.order.Len())
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		if e := elem.Value.(*entry[V]); now.Before(e.expires) {
+			keys = append(keys, e.key)
+		}
+	}
+	return keys
+}
+
+// Len retorna o número de entradas, incluindo as expiradas ainda não removidas
+func (c *LRU[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Stats retorna os contadores de uso do cache
+func (c *LRU[V]) Stats() Stats {
+	return Stats{
+		Size:        c.Len(),
+		Capacity:    c.capacity,
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+	}
+}
+
+// remove retira a entrada da lista e do mapa; deve ser chamada com o lock
+func (c *LRU[V]) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*entry[V]).key)
+}
@@ -0,0 +1,46 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	now := time.Now()
+	c := NewLRU[string](2, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("01001000", "São Paulo")
+	c.Set("29902555", "Linhares")
+	if _, ok := c.Get("01001000"); !ok {
+		t.Fatalf("Esperado acerto no cache")
+	}
+
+	// 29902555 é a entrada usada há mais tempo e deve sair
+	c.Set("20040020", "Rio de Janeiro")
+	if _, ok := c.Get("29902555"); ok {
+		t.Errorf("Entrada menos usada deveria ter sido removida")
+	}
+	if v, ok := c.Get("01001000"); !ok || v != "São Paulo" {
+		t.Errorf("Entrada recente não deveria ter sido removida")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get("20040020"); ok {
+		t.Errorf("Entrada expirada não deveria ser retornada")
+	}
+
+	stats := c.Stats()
+	want := Stats{Size: 1, Capacity: 2, Hits: 2, Misses: 2, Evictions: 1, Expirations: 1}
+	if stats != want {
+		t.Errorf("Contadores incorretos: obtido %+v, esperado %+v", stats, want)
+	}
+}
+
+func TestLRUDisabled(t *testing.T) {
+	c := NewLRU[string](0, time.Minute)
+	c.Set("01001000", "São Paulo")
+	if _, ok := c.Get("01001000"); ok || c.Len() != 0 {
+		t.Errorf("Cache com capacidade 0 não deveria guardar entradas")
+	}
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Memory é o backend em memória, com capacidade máxima e remoção LRU. Cada
+// processo tem o seu, por isso não é compartilhado entre réplicas.
+type Memory struct {
+	entries *LRU[[]byte]
+}
+
+// NewMemory cria um backend em memória com a capacidade informada
+func NewMemory(capacity int) *Memory {
+	return &Memory{entries: NewLRU[[]byte](capacity, 0)}
+}
+
+// Name retorna o nome do backend
+func (m *Memory) Name() string {
+	return "memory"
+}
+
+// Get retorna o valor da chave, se ele existir e não tiver expirado
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, ok := m.entries.Get(key)
+	return value, ok, nil
+}
+
+// Set grava o valor com a validade informada
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.entries.SetWithTTL(key, value, ttl)
+	return nil
+}
+
+// Delete remove a chave
+func (m *Memory) Delete(ctx context.Context, key string) error {
+	m.entries.Delete(key)
+	return nil
+}
+
+// Keys lista as chaves que começam com o prefixo
+func (m *Memory) Keys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	for _, key := range m.entries.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Stats retorna o tamanho e as remoções do cache; acertos e falhas são contados pelo Store
+func (m *Memory) Stats() Stats {
+	stats := m.entries.Stats()
+	return Stats{
+		Size:        stats.Size,
+		Capacity:    stats.Capacity,
+		Evictions:   stats.Evictions,
+		Expirations: stats.Expirations,
+	}
+}
@@ -0,0 +1,319 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Store guarda valores de um tipo em um Backend, serializados pelo Codec e com as
+// chaves prefixadas (prefixo + nome + ":" + chave). Falhas do backend são registradas
+// no span e tratadas como ausência do valor, para que o cache nunca derrube a consulta.
+type Store[V any] struct {
+	name    string
+	prefix  string
+	backend Backend
+	codec   Codec
+	tracer  trace.Tracer
+	now     func() time.Time
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	errors atomic.Uint64
+}
+
+// Managed reúne as operações de administração de um Store, independentes do tipo do valor
+type Managed interface {
+	Name() string
+	Inspect(ctx context.Context, key string) (*Entry, bool, error)
+	Entries(ctx context.Context) ([]Entry, error)
+	Remove(ctx context.Context, key string) (bool, error)
+	PurgePrefix(ctx context.Context, prefix string) (int, error)
+	Report(ctx context.Context) (Report, error)
+}
+
+// Valor gravado no backend, com os horários usados na administração do cache
+type envelope[V any] struct {
+	StoredAt  time.Time `json:"stored_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Value     V         `json:"value"`
+}
+
+// Entry descreve uma entrada do cache para a administração
+type Entry struct {
+	Cache     string      `json:"cache"`
+	Key       string      `json:"key"`
+	StoredAt  time.Time   `json:"stored_at"`
+	ExpiresAt time.Time   `json:"expires_at"`
+	Age       string      `json:"age"`
+	Value     interface{} `json:"value"`
+}
+
+// Report resume o estado de um cache: contadores, taxa de acerto e a distribuição
+// das entradas por idade
+type Report struct {
+	Stats
+	HitRatio float64        `json:"hit_ratio"`
+	Ages     map[string]int `json:"ages"`
+}
+
+// Faixas de idade do Report
+var ageBuckets = []struct {
+	label string
+	max   time.Duration
+}{
+	{"<1m", time.Minute},
+	{"1m-10m", 10 * time.Minute},
+	{"10m-1h", time.Hour},
+	{"1h-24h", 24 * time.Hour},
+	{">24h", 0},
+}
+
+// NewStore cria o Store de nome informado (por exemplo, "cep") sobre o backend
+func NewStore[V any](name string, backend Backend, prefix string, codec Codec) *Store[V] {
+	return &Store[V]{
+		name:    name,
+		prefix:  prefix + name + ":",
+		backend: backend,
+		codec:   codec,
+		tracer:  otel.GetTracerProvider().Tracer("weather-service"),
+		now:     time.Now,
+	}
+}
+
+// Name retorna o nome do Store
+func (s *Store[V]) Name() string {
+	return s.name
+}
+
+// Get busca o valor da chave
+func (s *Store[V]) Get(ctx context.Context, key string) (V, bool) {
+	return s.GetIf(ctx, key, nil)
+}
+
+// GetIf busca o valor da chave, que só é retornado (e contado como acerto) se usable o
+// aceitar; um valor recusado conta como falha, como se a chave não existisse. Com usable
+// nil, qualquer valor é aceito.
+func (s *Store[V]) GetIf(ctx context.Context, key string, usable func(V) bool) (V, bool) {
+	ctx, span := s.start(ctx, "cache-get", key)
+	defer span.End()
+
+	entry, ok, err := s.read(ctx, s.prefix+key)
+	if err != nil {
+		s.fail(span, "leitura", key, err)
+	}
+	if ok && usable != nil && !usable(entry.Value) {
+		var zero V
+		entry.Value, ok = zero, false
+	}
+
+	if ok {
+		s.hits.Add(1)
+	} else {
+		s.misses.Add(1)
+	}
+	span.SetAttributes(attribute.Bool("cache.hit", ok))
+	return entry.Value, ok
+}
+
+// Peek busca o valor da chave sem contar como acerto ou falha
+func (s *Store[V]) Peek(ctx context.Context, key string) (V, bool) {
+	ctx, span := s.start(ctx, "cache-peek", key)
+	defer span.End()
+
+	entry, ok, err := s.read(ctx, s.prefix+key)
+	if err != nil {
+		s.fail(span, "leitura", key, err)
+	}
+	return entry.Value, ok
+}
+
+// Set grava o valor na chave com a validade informada
+func (s *Store[V]) Set(ctx context.Context, key string, value V, ttl time.Duration) {
+	ctx, span := s.start(ctx, "cache-set", key)
+	defer span.End()
+	span.SetAttributes(attribute.String("cache.ttl", ttl.String()))
+
+	now := s.now()
+	data, err := s.codec.Marshal(envelope[V]{StoredAt: now, ExpiresAt: now.Add(ttl), Value: value})
+	if err == nil {
+		err = s.backend.Set(ctx, s.prefix+key, data, ttl)
+	}
+	if err != nil {
+		s.fail(span, "gravação", key, err)
+	}
+}
+
+// Delete remove a chave
+func (s *Store[V]) Delete(ctx context.Context, key string) {
+	ctx, span := s.start(ctx, "cache-delete", key)
+	defer span.End()
+
+	if err := s.backend.Delete(ctx, s.prefix+key); err != nil {
+		s.fail(span, "remoção", key, err)
+	}
+}
+
+// Inspect retorna a entrada da chave, sem contar como acerto ou falha
+func (s *Store[V]) Inspect(ctx context.Context, key string) (*Entry, bool, error) {
+	ctx, span := s.start(ctx, "cache-inspect", key)
+	defer span.End()
+
+	entry, ok, err := s.read(ctx, s.prefix+key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return s.describe(key, entry), true, nil
+}
+
+// Entries retorna todas as entradas do Store
+func (s *Store[V]) Entries(ctx context.Context) ([]Entry, error) {
+	ctx, span := s.start(ctx, "cache-scan", "")
+	defer span.End()
+
+	keys, err := s.backend.Keys(ctx, s.prefix)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(keys))
+	for _, full := range keys {
+		entry, ok, err := s.read(ctx, full)
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		if ok {
+			entries = append(entries, *s.describe(strings.TrimPrefix(full, s.prefix), entry))
+		}
+	}
+	span.SetAttributes(attribute.Int("cache.entries", len(entries)))
+	return entries, nil
+}
+
+// Remove apaga a chave, informando se ela existia
+func (s *Store[V]) Remove(ctx context.Context, key string) (bool, error) {
+	ctx, span := s.start(ctx, "cache-purge", key)
+	defer span.End()
+
+	_, ok, err := s.backend.Get(ctx, s.prefix+key)
+	if err == nil && ok {
+		err = s.backend.Delete(ctx, s.prefix+key)
+	}
+	if err != nil {
+		span.RecordError(err)
+		return false, err
+	}
+	span.SetAttributes(attribute.Bool("cache.removed", ok))
+	return ok, nil
+}
+
+// PurgePrefix remove as chaves que começam com o prefixo, retornando quantas saíram.
+// Um prefixo vazio esvazia o Store.
+func (s *Store[V]) PurgePrefix(ctx context.Context, prefix string) (int, error) {
+	ctx, span := s.start(ctx, "cache-purge", prefix)
+	defer span.End()
+
+	keys, err := s.backend.Keys(ctx, s.prefix+prefix)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	for i, key := range keys {
+		if err := s.backend.Delete(ctx, key); err != nil {
+			span.RecordError(err)
+			return i, err
+		}
+	}
+	span.SetAttributes(attribute.Int("cache.removed", len(keys)))
+	return len(keys), nil
+}
+
+// Report resume o estado do Store, percorrendo as entradas para calcular as idades
+func (s *Store[V]) Report(ctx context.Context) (Report, error) {
+	entries, err := s.Entries(ctx)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{Stats: s.Stats(), Ages: make(map[string]int, len(ageBuckets))}
+	report.Size = len(entries)
+	if total := report.Hits + report.Misses; total > 0 {
+		report.HitRatio = float64(report.Hits) / float64(total)
+	}
+
+	for _, bucket := range ageBuckets {
+		report.Ages[bucket.label] = 0
+	}
+	now := s.now()
+	for _, entry := range entries {
+		age := now.Sub(entry.StoredAt)
+		for _, bucket := range ageBuckets {
+			if bucket.max == 0 || age < bucket.max {
+				report.Ages[bucket.label]++
+				break
+			}
+		}
+	}
+	return report, nil
+}
+
+// Stats retorna os acertos e falhas do Store, somados ao tamanho e às remoções
+// do backend quando ele os informa
+func (s *Store[V]) Stats() Stats {
+	var stats Stats
+	if b, ok := s.backend.(interface{ Stats() Stats }); ok {
+		stats = b.Stats()
+	}
+	stats.Hits = s.hits.Load()
+	stats.Misses = s.misses.Load()
+	stats.Errors = s.errors.Load()
+	return stats
+}
+
+// read busca e decodifica a chave completa (já com o prefixo)
+func (s *Store[V]) read(ctx context.Context, key string) (envelope[V], bool, error) {
+	var entry envelope[V]
+	data, ok, err := s.backend.Get(ctx, key)
+	if err != nil || !ok {
+		return entry, false, err
+	}
+	if err := s.codec.Unmarshal(data, &entry); err != nil {
+		return entry, false, err
+	}
+	return entry, true, nil
+}
+
+func (s *Store[V]) describe(key string, entry envelope[V]) *Entry {
+	return &Entry{
+		Cache:     s.name,
+		Key:       key,
+		StoredAt:  entry.StoredAt,
+		ExpiresAt: entry.ExpiresAt,
+		Age:       s.now().Sub(entry.StoredAt).Round(time.Second).String(),
+		Value:     entry.Value,
+	}
+}
+
+func (s *Store[V]) start(ctx context.Context, name, key string) (context.Context, trace.Span) {
+	ctx, span := s.tracer.Start(ctx, name)
+	span.SetAttributes(
+		attribute.String("cache.name", s.name),
+		attribute.String("cache.backend", s.backend.Name()),
+		attribute.String("cache.key", s.prefix+key),
+	)
+	return ctx, span
+}
+
+func (s *Store[V]) fail(span trace.Span, op, key string, err error) {
+	s.errors.Add(1)
+	span.RecordError(err)
+	log.Printf("Erro na %s do cache %s (%s): %v", op, s.name, key, err)
+}
@@ -0,0 +1,147 @@
+// Package concurrency limita quantas requisições o serviço processa ao mesmo tempo, com
+// um limite que se ajusta à latência observada (AIMD): cada requisição rápida com o
+// serviço ocupado aumenta o limite em 1/limite (cerca de 1 por leva de requisições) e
+// uma requisição lenta ou que estourou o prazo o multiplica por Backoff. Acima do
+// limite, as requisições são recusadas antes de qualquer trabalho, em vez de
+// acumularem goroutines à espera de provedores lentos.
+package concurrency
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Config define os limites e a reação do ajuste
+type Config struct {
+	InitialLimit  int
+	MinLimit      int
+	MaxLimit      int           // 0 desativa o limitador
+	LatencyTarget time.Duration // Requisições mais lentas que isso reduzem o limite; 0 usa só os prazos estourados
+	Backoff       float64       // Fator aplicado ao limite na redução, entre 0 e 1
+}
+
+// FromEnv lê a configuração das variáveis <prefix>INITIAL_LIMIT, <prefix>MIN_LIMIT,
+// <prefix>MAX_LIMIT, <prefix>LATENCY_TARGET e <prefix>BACKOFF, usando os valores de def
+// para as ausentes ou inválidas
+func FromEnv(prefix string, def Config) Config {
+	config := def
+	if v, err := strconv.Atoi(os.Getenv(prefix + "INITIAL_LIMIT")); err == nil && v > 0 {
+		config.InitialLimit = v
+	}
+	if v, err := strconv.Atoi(os.Getenv(prefix + "MIN_LIMIT")); err == nil && v > 0 {
+		config.MinLimit = v
+	}
+	if v, err := strconv.Atoi(os.Getenv(prefix + "MAX_LIMIT")); err == nil && v >= 0 {
+		config.MaxLimit = v
+	}
+	if v, err := time.ParseDuration(os.Getenv(prefix + "LATENCY_TARGET")); err == nil {
+		config.LatencyTarget = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv(prefix+"BACKOFF"), 64); err == nil && v > 0 && v < 1 {
+		config.Backoff = v
+	}
+	return config
+}
+
+// Limiter controla as requisições em andamento
+type Limiter struct {
+	config Config
+	now    func() time.Time
+
+	mu           sync.Mutex
+	limit        float64
+	inFlight     int
+	lastDecrease time.Time
+	accepted     uint64
+	rejected     uint64
+	decreases    uint64
+}
+
+// Snapshot descreve o limitador para as métricas
+type Snapshot struct {
+	Limit     int    `json:"limit"`
+	InFlight  int    `json:"in_flight"`
+	MinLimit  int    `json:"min_limit"`
+	MaxLimit  int    `json:"max_limit"`
+	Accepted  uint64 `json:"accepted"`
+	Rejected  uint64 `json:"rejected"`
+	Decreases uint64 `json:"decreases"`
+}
+
+// New cria o limitador, começando em InitialLimit dentro de [MinLimit, MaxLimit]
+func New(config Config) *Limiter {
+	if config.MinLimit < 1 {
+		config.MinLimit = 1
+	}
+	if config.MaxLimit < config.MinLimit {
+		config.MaxLimit = config.MinLimit
+	}
+	if config.Backoff <= 0 || config.Backoff >= 1 {
+		config.Backoff = 0.9
+	}
+	limit := min(max(config.InitialLimit, config.MinLimit), config.MaxLimit)
+	return &Limiter{config: config, now: time.Now, limit: float64(limit)}
+}
+
+// Acquire reserva uma vaga para a requisição. Sem vaga, retorna false; com ela, retorna a
+// função que a devolve ao fim da requisição, informando se ela estourou o prazo.
+func (l *Limiter) Acquire() (release func(timedOut bool), ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight >= int(l.limit) {
+		l.rejected++
+		return nil, false
+	}
+	l.inFlight++
+	l.accepted++
+
+	start := l.now()
+	// Só aumenta o limite quem chegou com o serviço ocupado: com pouca carga, requisições
+	// rápidas não dizem nada sobre quanto o serviço aguenta
+	busy := float64(l.inFlight)*2 >= l.limit
+	var once sync.Once
+	return func(timedOut bool) {
+		once.Do(func() { l.release(start, busy, timedOut) })
+	}, true
+}
+
+func (l *Limiter) release(start time.Time, busy, timedOut bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	now := l.now()
+	slow := l.config.LatencyTarget > 0 && now.Sub(start) > l.config.LatencyTarget
+	switch {
+	case timedOut || slow:
+		// Requisições lentas chegam juntas; só a primeira de cada leva (iniciada depois da
+		// última redução) reduz o limite
+		if start.After(l.lastDecrease) {
+			l.limit = max(l.limit*l.config.Backoff, float64(l.config.MinLimit))
+			l.lastDecrease = now
+			l.decreases++
+		}
+	case busy:
+		l.limit = min(l.limit+1/l.limit, float64(l.config.MaxLimit))
+	}
+}
+
+// Snapshot retorna o limite atual, as requisições em andamento e os contadores
+func (l *Limiter) Snapshot() Snapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return Snapshot{
+		Limit:     int(math.Floor(l.limit)),
+		InFlight:  l.inFlight,
+		MinLimit:  l.config.MinLimit,
+		MaxLimit:  l.config.MaxLimit,
+		Accepted:  l.accepted,
+		Rejected:  l.rejected,
+		Decreases: l.decreases,
+	}
+}
@@ -0,0 +1,90 @@
+package concurrency
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterShedsAboveLimit(t *testing.T) {
+	l := New(Config{InitialLimit: 2, MinLimit: 1, MaxLimit: 10})
+
+	var releases []func(bool)
+	for i := 0; i < 2; i++ {
+		release, ok := l.Acquire()
+		if !ok {
+			t.Fatalf("Requisição %d deveria ser aceita", i+1)
+		}
+		releases = append(releases, release)
+	}
+	if _, ok := l.Acquire(); ok {
+		t.Fatal("Requisição acima do limite deveria ser recusada")
+	}
+
+	// Devolver a vaga mais de uma vez não libera vagas a mais
+	releases[0](false)
+	releases[0](false)
+	snapshot := l.Snapshot()
+	if snapshot.InFlight != 1 || snapshot.Accepted != 2 || snapshot.Rejected != 1 {
+		t.Errorf("Snapshot inesperado: %+v", snapshot)
+	}
+	if _, ok := l.Acquire(); !ok {
+		t.Error("Requisição deveria ser aceita depois de uma vaga devolvida")
+	}
+}
+
+func TestLimiterAdjustsToLatency(t *testing.T) {
+	now := time.Now()
+	l := New(Config{InitialLimit: 4, MinLimit: 2, MaxLimit: 5, LatencyTarget: time.Second, Backoff: 0.5})
+	l.now = func() time.Time { return now }
+
+	// Requisições rápidas com o serviço ocupado aumentam o limite, até o máximo
+	for i := 0; i < 40; i++ {
+		var releases []func(bool)
+		for j := 0; j < l.Snapshot().Limit; j++ {
+			release, _ := l.Acquire()
+			releases = append(releases, release)
+		}
+		for _, release := range releases {
+			release(false)
+		}
+	}
+	if got := l.Snapshot().Limit; got != 5 {
+		t.Fatalf("Limite deveria subir até o máximo, está %d", got)
+	}
+
+	// Uma leva de requisições lentas reduz o limite uma única vez
+	var releases []func(bool)
+	for i := 0; i < 3; i++ {
+		release, _ := l.Acquire()
+		releases = append(releases, release)
+	}
+	now = now.Add(2 * time.Second)
+	for _, release := range releases {
+		release(false)
+	}
+	snapshot := l.Snapshot()
+	if snapshot.Limit != 2 || snapshot.Decreases != 1 {
+		t.Errorf("Limite deveria cair para 2 em uma redução: %+v", snapshot)
+	}
+
+	// Prazo estourado reduz o limite, que não passa do mínimo
+	now = now.Add(time.Millisecond)
+	release, _ := l.Acquire()
+	release(true)
+	if snapshot := l.Snapshot(); snapshot.Limit != 2 || snapshot.Decreases != 2 {
+		t.Errorf("Limite não deveria passar do mínimo: %+v", snapshot)
+	}
+}
+
+func TestLimiterQuietLoadKeepsLimit(t *testing.T) {
+	l := New(Config{InitialLimit: 10, MinLimit: 1, MaxLimit: 100})
+
+	// Uma requisição por vez não indica quanto o serviço aguenta
+	for i := 0; i < 50; i++ {
+		release, _ := l.Acquire()
+		release(false)
+	}
+	if got := l.Snapshot().Limit; got != 10 {
+		t.Errorf("Limite não deveria mudar com pouca carga, está %d", got)
+	}
+}
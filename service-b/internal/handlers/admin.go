@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"cep-weather-api/pkg/admin"
+	"service-b/internal/cache"
+	"service-b/internal/services"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// HandleCacheAdmin administra os caches do serviço:
+//   - GET /admin/cache/stats: tamanho, taxa de acerto e idades das entradas de cada cache
+//   - GET /admin/cache/entries?cep=...|city=...: entradas de um CEP ou de uma cidade
+//   - DELETE /admin/cache/entries?cache=...&key=...|prefix=...: remove uma chave ou as
+//     chaves com o prefixo, em um cache ou, sem cache, em todos
+//   - DELETE /admin/cache: esvazia todos os caches
+//
+// Cada operação roda em um span próprio e as remoções são registradas na auditoria.
+func HandleCacheAdmin(weatherService *services.WeatherService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := otel.GetTracerProvider().Tracer("service-b-handlers").Start(r.Context(), "admin-cache")
+		defer span.End()
+		span.SetAttributes(
+			attribute.String("http.method", r.Method),
+			attribute.String("http.path", r.URL.Path),
+		)
+
+		query := r.URL.Query()
+		var result interface{}
+		var err error
+
+		switch path := strings.TrimSuffix(r.URL.Path, "/"); {
+		case path == "/admin/cache/stats" && r.Method == http.MethodGet:
+			result, err = weatherService.CacheReport(ctx)
+
+		case path == "/admin/cache/entries" && r.Method == http.MethodGet:
+			if query.Get("cep") == "" && query.Get("city") == "" {
+				http.Error(w, "cep or city is required", http.StatusBadRequest)
+				return
+			}
+			var entries []cache.Entry
+			entries, err = weatherService.InspectCache(ctx, query.Get("cep"), query.Get("city"))
+			if err == nil && len(entries) == 0 {
+				http.Error(w, "no cache entries found", http.StatusNotFound)
+				return
+			}
+			result = entries
+
+		case path == "/admin/cache/entries" && r.Method == http.MethodDelete:
+			name, key, prefix := query.Get("cache"), query.Get("key"), query.Get("prefix")
+			if key == "" && prefix == "" {
+				http.Error(w, "key or prefix is required", http.StatusBadRequest)
+				return
+			}
+			var removed int
+			removed, err = weatherService.PurgeCache(ctx, name, key, prefix)
+			admin.Audit(ctx, r, "cache.purge",
+				attribute.String("cache.name", name),
+				attribute.String("cache.key", key),
+				attribute.String("cache.prefix", prefix),
+				attribute.Int("cache.removed", removed),
+			)
+			result = map[string]int{"removed": removed}
+
+		case path == "/admin/cache" && r.Method == http.MethodDelete:
+			var removed int
+			removed, err = weatherService.FlushCache(ctx)
+			admin.Audit(ctx, r, "cache.flush", attribute.Int("cache.removed", removed))
+			result = map[string]int{"removed": removed}
+
+		default:
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, services.ErrUnknownCache) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			span.RecordError(err)
+			log.Printf("Erro na administração do cache: %v", err)
+			http.Error(w, "Error accessing cache", http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"service-b/internal/models"
+)
+
+// Políticas de degradação, aplicadas quando os provedores de clima falham
+const (
+	// DegradeNone responde com erro, como sem degradação
+	DegradeNone = "none"
+	// DegradeStale responde com a última leitura conhecida (stale: true) ou, sem ela, com erro
+	DegradeStale = "stale"
+	// DegradePartial responde com a última leitura conhecida ou, sem ela, apenas com a
+	// cidade (weather_unavailable: true)
+	DegradePartial = "partial"
+)
+
+// ParseDegradation valida a política informada; vazia equivale a DegradeNone
+func ParseDegradation(policy string) (string, error) {
+	switch policy {
+	case "":
+		return DegradeNone, nil
+	case DegradeNone, DegradeStale, DegradePartial:
+		return policy, nil
+	}
+	return "", fmt.Errorf("invalid degradation policy %q (expected none, stale or partial)", policy)
+}
+
+// degradedResponse monta a resposta degradada do endereço conforme a política, informando
+// false quando a política não permite responder sem uma leitura atual
+func degradedResponse(ctx context.Context, weatherService WeatherLookup, address *models.Address, policy string) (*models.WeatherResponse, bool) {
+	if policy == DegradeNone {
+		return nil, false
+	}
+
+	// O prazo da requisição pode ter acabado, mas a consulta ao cache ainda vale a pena
+	ctx = context.WithoutCancel(ctx)
+	if reading, observedAt, ok := weatherService.LastKnownTemperature(ctx, address); ok {
+		response := weatherResponse(address, reading)
+		response.Stale = true
+		response.ObservedAt = &observedAt
+		return response, true
+	}
+
+	if policy != DegradePartial {
+		return nil, false
+	}
+	return &models.WeatherResponse{
+		City:               address.Localidade,
+		CEPProvider:        address.Provider,
+		WeatherUnavailable: true,
+	}, true
+}
+
+// weatherResponse monta a resposta com a leitura e as temperaturas convertidas
+func weatherResponse(address *models.Address, reading *models.WeatherReading) *models.WeatherResponse {
+	tempC := reading.TempC
+	tempF := tempC*1.8 + 32
+	tempK := tempC + 273
+	return &models.WeatherResponse{
+		City:               address.Localidade,
+		TempC:              &tempC,
+		TempF:              &tempF,
+		TempK:              &tempK,
+		CEPProvider:        address.Provider,
+		LocationConfidence: reading.LocationConfidence,
+		Consensus:          reading.Consensus,
+	}
+}
@@ -3,13 +3,22 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"regexp"
+	"strconv"
+	"sync/atomic"
+	"time"
 
+	"cep-weather-api/pkg/deadline"
+	"cep-weather-api/pkg/faults"
+	"service-b/internal/concurrency"
 	"service-b/internal/models"
+	"service-b/internal/ratelimit"
 	"service-b/internal/services"
 
 	"go.opentelemetry.io/otel"
@@ -72,23 +81,118 @@ func InitTracer() func() {
 	}
 }
 
-// HandleHealthCheck verifica se o serviço está ativo
-func HandleHealthCheck(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"status": "ok",
-	})
+// HandleHealthCheck verifica se o serviço está ativo. Com o circuito de algum provedor
+// aberto o status passa a "degraded", mas a resposta continua 200: o serviço segue
+// respondendo com os demais provedores e os caches.
+func HandleHealthCheck(weatherService *services.WeatherService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := "ok"
+		if len(weatherService.OpenBreakers()) > 0 {
+			status = "degraded"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status":   status,
+			"breakers": weatherService.BreakerStates(),
+		})
+	}
+}
+
+// HandleReadiness informa se o serviço está pronto para receber tráfego, o que só
+// acontece depois do aquecimento dos caches
+func HandleReadiness(ready *atomic.Bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if !ready.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{"status": "warming up"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"status": "ready"})
+	}
+}
+
+// HandleMetrics expõe os contadores do serviço em JSON
+func HandleMetrics(weatherService *services.WeatherService, limiter *concurrency.Limiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		metrics := map[string]interface{}{
+			"cache":    weatherService.CacheStats(),
+			"breakers": weatherService.BreakerStates(),
+			"quota":    weatherService.QuotaUsage(),
+		}
+		if limiter != nil {
+			metrics["concurrency"] = limiter.Snapshot()
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(metrics)
+	}
+}
+
+// LimitConcurrency recusa com 503, antes de qualquer trabalho, as requisições acima do
+// limite de concorrência; as aceitas devolvem a vaga ao terminar, e um 504 conta como
+// prazo estourado no ajuste do limite. Com limiter nil, não limita.
+func LimitConcurrency(limiter *concurrency.Limiter, next http.HandlerFunc) http.HandlerFunc {
+	if limiter == nil {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		release, ok := limiter.Acquire()
+		if !ok {
+			snapshot := limiter.Snapshot()
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			_, span := tracer.Start(ctx, "shed-request", trace.WithAttributes(
+				attribute.Int("concurrency.limit", snapshot.Limit),
+				attribute.Int("concurrency.in_flight", snapshot.InFlight),
+			))
+			span.End()
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "server overloaded", http.StatusServiceUnavailable)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() { release(recorder.status == http.StatusGatewayTimeout) }()
+		next(recorder, r)
+	}
+}
+
+// statusRecorder guarda o status escrito na resposta
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
 }
 
-// HandleWeatherRequest processa as requisições de CEP e retorna os dados de temperatura
-func HandleWeatherRequest(weatherService *services.WeatherService) http.HandlerFunc {
+// WeatherLookup reúne as consultas que HandleWeatherRequest faz ao serviço de clima,
+// implementadas por *services.WeatherService
+type WeatherLookup interface {
+	GetCityByCEP(ctx context.Context, cep string) (*models.Address, error)
+	GetTemperature(ctx context.Context, address *models.Address) (*models.WeatherReading, error)
+	LastKnownTemperature(ctx context.Context, address *models.Address) (*models.WeatherReading, time.Time, bool)
+}
+
+// HandleWeatherRequest processa as requisições de CEP e retorna os dados de temperatura.
+// Quando os provedores de clima falham, aplica a política de degradação da requisição
+// (campo "degradation") ou, sem ela, a informada.
+func HandleWeatherRequest(weatherService WeatherLookup, degradation string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Extrair o contexto de propagação do cabeçalho da requisição
 		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
 		ctx, span := tracer.Start(ctx, "handle-weather-request")
 		defer span.End()
 
+		// O orçamento enviado pelo service-a vira o prazo de todas as chamadas da requisição
+		ctx, cancel, budget := deadline.FromHeader(ctx, r.Header)
+		defer cancel()
+		if budget > 0 {
+			span.SetAttributes(attribute.Int64("request.budget_ms", budget.Milliseconds()))
+		}
+
 		// Aceita apenas método POST
 		if r.Method != http.MethodPost {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -111,6 +215,15 @@ func HandleWeatherRequest(weatherService *services.WeatherService) http.HandlerF
 			return
 		}
 
+		// Política de degradação da requisição, no lugar da global
+		policy := degradation
+		if payload.Degradation != "" {
+			if policy, err = ParseDegradation(payload.Degradation); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
 		// Obter o CEP do payload
 		cep := payload.CEP
 
@@ -122,35 +235,68 @@ func HandleWeatherRequest(weatherService *services.WeatherService) http.HandlerF
 			return
 		}
 
+		// O CEP acompanha o contexto para as regras de injeção de falhas
+		ctx = faults.WithCEP(ctx, cep)
+
 		// Buscar cidade pelo CEP
-		cidade, err := weatherService.GetCityByCEP(ctx, cep)
+		address, err := weatherService.GetCityByCEP(ctx, cep)
 		if err != nil {
-			w.WriteHeader(http.StatusNotFound)
-			w.Write([]byte("can not find zipcode"))
+			if errors.Is(err, services.ErrCEPNotFound) {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte("can not find zipcode"))
+				return
+			}
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				log.Printf("Prazo da requisição esgotado ao consultar o CEP: %v", err)
+				http.Error(w, "request deadline exceeded", http.StatusGatewayTimeout)
+				return
+			}
+			log.Printf("Erro ao consultar provedores de CEP: %v", err)
+			http.Error(w, "Error looking up zipcode", http.StatusBadGateway)
 			return
 		}
+		span.SetAttributes(attribute.String("cep.provider", address.Provider))
 
 		// Buscar temperatura
-		tempC, err := weatherService.GetTemperature(ctx, cidade)
+		reading, err := weatherService.GetTemperature(ctx, address)
 		if err != nil {
+			// Com os provedores fora, a política pode permitir a última leitura ou só a cidade
+			if response, ok := degradedResponse(ctx, weatherService, address, policy); ok {
+				log.Printf("Erro ao obter temperatura, resposta degradada (%s): %v", policy, err)
+				span.RecordError(err)
+				span.SetAttributes(
+					attribute.String("degradation.policy", policy),
+					attribute.Bool("degradation.stale", response.Stale),
+					attribute.Bool("degradation.weather_unavailable", response.WeatherUnavailable),
+				)
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(response)
+				return
+			}
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				log.Printf("Prazo da requisição esgotado ao obter a temperatura: %v", err)
+				http.Error(w, "request deadline exceeded", http.StatusGatewayTimeout)
+				return
+			}
+			var limited *ratelimit.LimitedError
+			if errors.As(err, &limited) {
+				log.Printf("Cota do provedor de clima esgotada: %v", err)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+				http.Error(w, "weather provider quota exceeded", http.StatusServiceUnavailable)
+				return
+			}
 			log.Printf("Erro ao obter temperatura: %v", err)
 			http.Error(w, "Error getting temperature", http.StatusInternalServerError)
 			return
 		}
 
-		// Converter temperaturas
-		tempF := tempC*1.8 + 32
-		tempK := tempC + 273
-
-		// Montar resposta
-		response := models.WeatherResponse{
-			City:  cidade,
-			TempC: tempC,
-			TempF: tempF,
-			TempK: tempK,
-		}
+		// Montar resposta, com as temperaturas convertidas
+		response := weatherResponse(address, reading)
 
 		// Enviar resposta
+		if reading.CacheStatus != "" {
+			w.Header().Set("X-Cache", reading.CacheStatus)
+		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(response)
 	}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"cep-weather-api/pkg/deadline"
+	"service-b/internal/concurrency"
+	"service-b/internal/models"
+	"service-b/internal/ratelimit"
+
+	"go.opentelemetry.io/otel"
+)
+
+func init() {
+	// Sem InitTracer, que exporta para o Zipkin, os handlers usam o tracer global (no-op)
+	tracer = otel.GetTracerProvider().Tracer("service-b-handlers")
+}
+
+func TestLimitConcurrencyShedsRequests(t *testing.T) {
+	limiter := concurrency.New(concurrency.Config{InitialLimit: 1, MaxLimit: 1})
+
+	started, unblock := make(chan struct{}), make(chan struct{})
+	calls := 0
+	handler := LimitConcurrency(limiter, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		close(started)
+		<-unblock
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	}()
+	<-started
+
+	// Com a única vaga ocupada, a requisição seguinte é recusada sem chegar ao handler
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("Esperado 503 com Retry-After, obtido %d %v", rec.Code, rec.Header())
+	}
+
+	close(unblock)
+	<-done
+	if calls != 1 {
+		t.Errorf("Handler deveria ser chamado uma vez, foi %d", calls)
+	}
+	if snapshot := limiter.Snapshot(); snapshot.InFlight != 0 || snapshot.Rejected != 1 {
+		t.Errorf("Vaga deveria ser devolvida ao fim da requisição: %+v", snapshot)
+	}
+}
+
+func TestLimitConcurrencyTimeoutReducesLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		wantDecreases uint64
+	}{
+		{"sucesso mantém o limite", http.StatusOK, 0},
+		{"erro dos provedores mantém o limite", http.StatusBadGateway, 0},
+		{"prazo estourado reduz o limite", http.StatusGatewayTimeout, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := concurrency.New(concurrency.Config{InitialLimit: 10, MaxLimit: 10, Backoff: 0.5})
+			handler := LimitConcurrency(limiter, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			})
+
+			handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+			snapshot := limiter.Snapshot()
+			if snapshot.InFlight != 0 || snapshot.Decreases != tt.wantDecreases {
+				t.Errorf("Limitador incorreto após status %d: %+v", tt.status, snapshot)
+			}
+		})
+	}
+}
+
+func TestLimitConcurrencyDisabled(t *testing.T) {
+	calls := 0
+	next := func(w http.ResponseWriter, r *http.Request) { calls++ }
+	LimitConcurrency(nil, next)(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	if calls != 1 {
+		t.Errorf("Sem limitador, o handler deveria ser chamado")
+	}
+}
+
+// stubWeatherService resolve todo CEP para São Paulo e responde à consulta de temperatura
+// com readingErr, quando informado; context.DeadlineExceeded espera o prazo da requisição
+type stubWeatherService struct {
+	readingErr error
+	lastKnown  *models.WeatherReading
+	observedAt time.Time
+}
+
+func (s *stubWeatherService) GetCityByCEP(ctx context.Context, cep string) (*models.Address, error) {
+	return &models.Address{Cep: cep, Localidade: "São Paulo", Uf: "SP", Provider: "viacep"}, nil
+}
+
+func (s *stubWeatherService) GetTemperature(ctx context.Context, address *models.Address) (*models.WeatherReading, error) {
+	if errors.Is(s.readingErr, context.DeadlineExceeded) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	if s.readingErr != nil {
+		return nil, s.readingErr
+	}
+	return &models.WeatherReading{Provider: "fixture", TempC: 25}, nil
+}
+
+func (s *stubWeatherService) LastKnownTemperature(ctx context.Context, address *models.Address) (*models.WeatherReading, time.Time, bool) {
+	return s.lastKnown, s.observedAt, s.lastKnown != nil
+}
+
+func TestHandleWeatherRequestDegradation(t *testing.T) {
+	observedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	lastKnown := &models.WeatherReading{Provider: "fixture", TempC: 18}
+	errProvider := errors.New("provider unavailable")
+	errQuota := &ratelimit.LimitedError{Provider: "weatherapi", Reason: "monthly-quota", RetryAfter: 90 * time.Second}
+
+	tests := []struct {
+		name        string
+		global      string // Política global do serviço
+		body        string
+		readingErr  error
+		lastKnown   *models.WeatherReading
+		wantStatus  int
+		wantBody    string // Trecho esperado no corpo de erro
+		wantStale   bool
+		wantPartial bool
+	}{
+		{"sem falha", DegradeStale, `{"cep": "01001000"}`, nil, lastKnown, http.StatusOK, "", false, false},
+		{"none mantém o erro", DegradeNone, `{"cep": "01001000"}`, errProvider, lastKnown, http.StatusInternalServerError, "Error getting temperature", false, false},
+		{"none mantém o prazo esgotado", DegradeNone, `{"cep": "01001000"}`, context.DeadlineExceeded, lastKnown, http.StatusGatewayTimeout, "request deadline exceeded", false, false},
+		{"none mantém a cota esgotada", DegradeNone, `{"cep": "01001000"}`, errQuota, lastKnown, http.StatusServiceUnavailable, "weather provider quota exceeded", false, false},
+		{"stale responde com a última leitura", DegradeStale, `{"cep": "01001000"}`, errProvider, lastKnown, http.StatusOK, "", true, false},
+		{"stale no prazo esgotado", DegradeStale, `{"cep": "01001000"}`, context.DeadlineExceeded, lastKnown, http.StatusOK, "", true, false},
+		{"stale na cota esgotada", DegradeStale, `{"cep": "01001000"}`, errQuota, lastKnown, http.StatusOK, "", true, false},
+		{"stale sem leitura mantém o erro", DegradeStale, `{"cep": "01001000"}`, errProvider, nil, http.StatusInternalServerError, "Error getting temperature", false, false},
+		{"partial sem leitura responde só a cidade", DegradePartial, `{"cep": "01001000"}`, errQuota, nil, http.StatusOK, "", false, true},
+		{"partial prefere a última leitura", DegradePartial, `{"cep": "01001000"}`, errProvider, lastKnown, http.StatusOK, "", true, false},
+		{"política da requisição no lugar da global", DegradeNone, `{"cep": "01001000", "degradation": "partial"}`, errProvider, nil, http.StatusOK, "", false, true},
+		{"requisição pode desativar a degradação", DegradePartial, `{"cep": "01001000", "degradation": "none"}`, errProvider, lastKnown, http.StatusInternalServerError, "Error getting temperature", false, false},
+		{"política inválida", DegradeNone, `{"cep": "01001000", "degradation": "always"}`, nil, nil, http.StatusBadRequest, "invalid degradation policy", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &stubWeatherService{readingErr: tt.readingErr, lastKnown: tt.lastKnown, observedAt: observedAt}
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set(deadline.Header, "50")
+			rec := httptest.NewRecorder()
+			HandleWeatherRequest(service, tt.global)(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Status incorreto: obtido %d %q, esperado %d", rec.Code, rec.Body.String(), tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if !strings.Contains(rec.Body.String(), tt.wantBody) {
+					t.Errorf("Corpo incorreto: %q", rec.Body.String())
+				}
+				if tt.wantStatus == http.StatusServiceUnavailable && rec.Header().Get("Retry-After") != "90" {
+					t.Errorf("Cota esgotada deveria informar Retry-After: %v", rec.Header())
+				}
+				return
+			}
+
+			var response models.WeatherResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("Resposta inválida: %v", err)
+			}
+			if response.City != "São Paulo" || response.Stale != tt.wantStale || response.WeatherUnavailable != tt.wantPartial {
+				t.Errorf("Resposta incorreta: %+v", response)
+			}
+			switch {
+			case tt.wantStale:
+				if response.TempC == nil || *response.TempC != 18 || response.ObservedAt == nil || !response.ObservedAt.Equal(observedAt) {
+					t.Errorf("Deveria responder com a última leitura: %+v", response)
+				}
+			case tt.wantPartial:
+				if response.TempC != nil || response.ObservedAt != nil {
+					t.Errorf("Resposta parcial não deveria ter temperatura: %+v", response)
+				}
+			default:
+				if response.TempC == nil || *response.TempC != 25 {
+					t.Errorf("Deveria responder com a leitura atual: %+v", response)
+				}
+			}
+		})
+	}
+}
@@ -1,8 +1,11 @@
 package models
 
+import "time"
+
 // Requisição recebida do Serviço A
 type CEPRequest struct {
-	CEP string `json:"cep"`
+	CEP         string `json:"cep"`
+	Degradation string `json:"degradation,omitempty"` // Política de degradação da requisição; vazia usa a global
 }
 
 // Resposta da consulta ViaCEP
@@ -20,6 +23,54 @@ type ViaCEPResponse struct {
 	Erro        bool   `json:"erro,omitempty"`
 }
 
+// Endereço normalizado, comum a todos os provedores de CEP
+type Address struct {
+	Cep         string       `json:"cep"`
+	Logradouro  string       `json:"logradouro"`
+	Complemento string       `json:"complemento"`
+	Bairro      string       `json:"bairro"`
+	Localidade  string       `json:"localidade"` // Nome da cidade
+	Uf          string       `json:"uf"`
+	Ibge        string       `json:"ibge"`
+	Ddd         string       `json:"ddd"`
+	Coordinates *Coordinates `json:"coordinates,omitempty"` // Nem todo provedor informa
+	Provider    string       `json:"provider"`              // Provedor que resolveu o CEP
+}
+
+// Coordenadas geográficas em graus decimais
+type Coordinates struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Resposta da BrasilAPI (v2)
+type BrasilAPIResponse struct {
+	Cep          string `json:"cep"`
+	State        string `json:"state"`
+	City         string `json:"city"`
+	Neighborhood string `json:"neighborhood"`
+	Street       string `json:"street"`
+	Service      string `json:"service"`
+	Location     struct {
+		Type        string `json:"type"`
+		Coordinates struct {
+			Longitude string `json:"longitude"`
+			Latitude  string `json:"latitude"`
+		} `json:"coordinates"`
+	} `json:"location"`
+}
+
+// Resposta da OpenCEP
+type OpenCEPResponse struct {
+	Cep         string `json:"cep"`
+	Logradouro  string `json:"logradouro"`
+	Complemento string `json:"complemento"`
+	Bairro      string `json:"bairro"`
+	Localidade  string `json:"localidade"`
+	Uf          string `json:"uf"`
+	Ibge        string `json:"ibge"`
+}
+
 // Resposta da WeatherAPI
 type WeatherAPIResponse struct {
 	Location struct {
@@ -30,9 +81,10 @@ type WeatherAPIResponse struct {
 		Lon     float64 `json:"lon"`
 	} `json:"location"`
 	Current struct {
-		TempC     float64 `json:"temp_c"`
-		TempF     float64 `json:"temp_f"`
-		Condition struct {
+		TempC            float64 `json:"temp_c"`
+		TempF            float64 `json:"temp_f"`
+		LastUpdatedEpoch int64   `json:"last_updated_epoch"`
+		Condition        struct {
 			Text string `json:"text"`
 			Icon string `json:"icon"`
 			Code int    `json:"code"`
@@ -40,10 +92,84 @@ type WeatherAPIResponse struct {
 	} `json:"current"`
 }
 
+// Resposta da API de geocodificação da Open-Meteo
+type OpenMeteoGeocodingResponse struct {
+	Results []struct {
+		Name        string  `json:"name"`
+		Latitude    float64 `json:"latitude"`
+		Longitude   float64 `json:"longitude"`
+		CountryCode string  `json:"country_code"`
+		Admin1      string  `json:"admin1"` // Estado
+	} `json:"results"`
+}
+
+// Resposta da API de previsão da Open-Meteo
+type OpenMeteoForecastResponse struct {
+	Current struct {
+		Time          string  `json:"time"`
+		Interval      int     `json:"interval"`
+		Temperature2m float64 `json:"temperature_2m"`
+		WeatherCode   int     `json:"weather_code"`
+	} `json:"current"`
+}
+
+// Localidade usada para consultar o clima
+type WeatherQuery struct {
+	City        string
+	Uf          string
+	Coordinates *Coordinates // Quando nil, a consulta é feita pelo nome da cidade
+	Qualified   bool         // Consulta pelo nome qualificado com UF e país ("cidade, UF, Brazil")
+}
+
+// Leitura de temperatura normalizada, comum a todos os provedores de clima
+type WeatherReading struct {
+	Provider           string
+	TempC              float64
+	Condition          string
+	Location           *ResolvedLocation // Localidade que o provedor usou, quando informada
+	LocationConfidence string            // high, low ou unverified
+	Consensus          *WeatherConsensus // Preenchido apenas no modo de consenso
+	ObservedAt         time.Time         // Momento da observação, quando o provedor informa
+	UpdateInterval     time.Duration     // Intervalo entre as atualizações do provedor
+	CacheStatus        string            // HIT, STALE ou MISS
+}
+
+// Consenso entre as leituras de vários provedores de clima
+type WeatherConsensus struct {
+	MedianC  float64           `json:"median_C"`
+	MinC     float64           `json:"min_C"`
+	MaxC     float64           `json:"max_C"`
+	SpreadC  float64           `json:"spread_C"`
+	Readings []ProviderReading `json:"readings"`
+	Failed   []string          `json:"failed,omitempty"` // Provedores que falharam ou não responderam a tempo
+}
+
+// Leitura individual de um provedor no modo de consenso
+type ProviderReading struct {
+	Provider           string  `json:"provider"`
+	TempC              float64 `json:"temp_C"`
+	LocationConfidence string  `json:"location_confidence,omitempty"`
+}
+
+// Localidade resolvida pelo provedor de clima
+type ResolvedLocation struct {
+	Name    string
+	Region  string
+	Country string
+	Lat     float64
+	Lon     float64
+}
+
 // Resposta final com os dados de temperatura
 type WeatherResponse struct {
-	City  string  `json:"city"`
-	TempC float64 `json:"temp_C"`
-	TempF float64 `json:"temp_F"`
-	TempK float64 `json:"temp_K"`
+	City               string            `json:"city"`
+	TempC              *float64          `json:"temp_C,omitempty"` // Ausentes quando o clima está indisponível
+	TempF              *float64          `json:"temp_F,omitempty"`
+	TempK              *float64          `json:"temp_K,omitempty"`
+	CEPProvider        string            `json:"cep_provider,omitempty"`        // Provedor que resolveu o CEP
+	LocationConfidence string            `json:"location_confidence,omitempty"` // high, low ou unverified
+	Consensus          *WeatherConsensus `json:"consensus,omitempty"`           // Apenas no modo de consenso
+	Stale              bool              `json:"stale,omitempty"`               // Última leitura conhecida, servida com os provedores fora
+	ObservedAt         *time.Time        `json:"observed_at,omitempty"`         // Momento da observação da leitura antiga
+	WeatherUnavailable bool              `json:"weather_unavailable,omitempty"` // Apenas a cidade, sem leitura disponível
 }
@@ -0,0 +1,425 @@
+// Package ratelimit limita as chamadas de saída a um provedor para respeitar as cotas
+// do plano contratado: um token bucket (chamadas por minuto, com rajada) e uma cota
+// mensal. Sem token disponível, a chamada espera na fila até o prazo ou falha na hora.
+// O limite por minuto é de cada processo. A cota mensal fica em um Counter compartilhado
+// entre as réplicas, quando informado; sem ele, fica na memória do processo, recomeça
+// a cada reinício e só é exata com uma réplica que não reinicia.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+// ErrLimited indica uma chamada recusada pelo limite de taxa ou pela cota mensal
+var ErrLimited = errors.New("rate limit exceeded")
+
+// LimitedError detalha a recusa, com o tempo até haver capacidade de novo
+type LimitedError struct {
+	Provider   string
+	Reason     string // "rate" ou "monthly-quota"
+	RetryAfter time.Duration
+}
+
+func (e *LimitedError) Error() string {
+	return fmt.Sprintf("%s: %s %s, retry after %s", ErrLimited, e.Provider, e.Reason, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LimitedError) Is(target error) bool {
+	return target == ErrLimited
+}
+
+// Config define as cotas de um provedor. Zero em PerMinute ou MonthlyQuota desativa o limite.
+type Config struct {
+	PerMinute    int           // Chamadas por minuto
+	Burst        int           // Chamadas seguidas permitidas antes de espaçar; padrão 1
+	MonthlyQuota int           // Chamadas por mês (calendário UTC)
+	Wait         bool          // Espera na fila por um token em vez de falhar na hora
+	MaxWait      time.Duration // Espera máxima na fila, além do prazo do contexto
+
+	// Counter guarda a contagem da cota mensal, compartilhada entre as réplicas, na
+	// chave KeyPrefix + "quota:<provedor>:<AAAA-MM>"; nil conta só neste processo
+	Counter   Counter
+	KeyPrefix string
+}
+
+// Counter é um contador compartilhado entre as réplicas do serviço, como o do Redis
+type Counter interface {
+	// IncrBy soma n ao contador, que vence em expireAt, e retorna o novo valor
+	IncrBy(ctx context.Context, key string, n int64, expireAt time.Time) (int64, error)
+}
+
+// Tempo máximo de uma operação no Counter, também usado para devolver uma reserva
+// depois que o contexto da chamada terminou
+const counterTimeout = time.Second
+
+// FromEnv lê a configuração das variáveis <prefix>RATE_PER_MINUTE, <prefix>RATE_BURST,
+// <prefix>MONTHLY_QUOTA, <prefix>RATE_LIMIT_MODE (wait ou fail) e <prefix>RATE_LIMIT_MAX_WAIT,
+// usando os valores de def para as ausentes ou inválidas
+func FromEnv(prefix string, def Config) Config {
+	config := def
+	if v, err := strconv.Atoi(os.Getenv(prefix + "RATE_PER_MINUTE")); err == nil {
+		config.PerMinute = v
+	}
+	if v, err := strconv.Atoi(os.Getenv(prefix + "RATE_BURST")); err == nil {
+		config.Burst = v
+	}
+	if v, err := strconv.Atoi(os.Getenv(prefix + "MONTHLY_QUOTA")); err == nil {
+		config.MonthlyQuota = v
+	}
+	switch strings.ToLower(os.Getenv(prefix + "RATE_LIMIT_MODE")) {
+	case "wait":
+		config.Wait = true
+	case "fail":
+		config.Wait = false
+	}
+	if v, err := time.ParseDuration(os.Getenv(prefix + "RATE_LIMIT_MAX_WAIT")); err == nil {
+		config.MaxWait = v
+	}
+	return config
+}
+
+// Limiter aplica as cotas de um provedor e conta o consumo
+type Limiter struct {
+	name    string
+	config  Config
+	limiter *rate.Limiter // nil sem limite por minuto
+	now     func() time.Time
+	started time.Time // Início da contagem neste processo
+
+	mu       sync.Mutex
+	minute   time.Time // Início do minuto corrente
+	month    time.Time // Início do mês corrente
+	byMinute int
+	byMonth  int
+	total    uint64
+	queued   uint64
+	rejected uint64
+	waited   time.Duration
+}
+
+// Usage descreve o consumo das cotas de um provedor para as métricas, não o consumo
+// registrado pelo provedor. Com um Counter, UsedThisMonth é o total de todas as
+// réplicas visto na última chamada deste processo; sem ele, é deste processo desde
+// CountingSince.
+type Usage struct {
+	Scope            string    `json:"scope"`          // "shared" com a cota mensal no Counter, "process" fora isso
+	CountingSince    time.Time `json:"counting_since"` // Início da contagem do mês corrente
+	PerMinuteLimit   int       `json:"per_minute_limit,omitempty"`
+	UsedThisMinute   int       `json:"used_this_minute"`
+	MonthlyQuota     int       `json:"monthly_quota,omitempty"`
+	UsedThisMonth    int       `json:"used_this_month"`
+	MonthlyRemaining *int      `json:"monthly_remaining,omitempty"`
+	Total            uint64    `json:"total"`
+	Queued           uint64    `json:"queued"`
+	Rejected         uint64    `json:"rejected"`
+	AvgWaitMs        float64   `json:"avg_wait_ms"`
+}
+
+// New cria o limitador do provedor informado
+func New(name string, config Config) *Limiter {
+	l := &Limiter{name: name, config: config, now: time.Now}
+	l.started = l.now()
+	if config.PerMinute > 0 {
+		burst := config.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		l.limiter = rate.NewLimiter(rate.Limit(float64(config.PerMinute)/60), burst)
+	}
+	return l
+}
+
+// Enabled informa se o limitador tem algum limite configurado
+func (l *Limiter) Enabled() bool {
+	return l.config.PerMinute > 0 || l.config.MonthlyQuota > 0
+}
+
+// Acquire obtém permissão para uma chamada. Esgotada a cota mensal, falha na hora; sem
+// token, espera até MaxWait e o prazo do contexto (no modo de espera) ou falha na hora.
+// Retorna quanto tempo a chamada esperou na fila.
+func (l *Limiter) Acquire(ctx context.Context) (time.Duration, error) {
+	now := l.now()
+	reserved, err := l.reserveMonthly(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	release := func() {
+		if reserved {
+			l.releaseMonthly(ctx, now)
+		}
+	}
+
+	var delay time.Duration
+	if l.limiter != nil {
+		reservation := l.limiter.ReserveN(now, 1)
+		if !reservation.OK() {
+			release()
+			return 0, l.reject("rate", time.Minute)
+		}
+		delay = reservation.DelayFrom(now)
+		if delay > 0 {
+			maxWait := time.Duration(0)
+			if l.config.Wait {
+				maxWait = l.config.MaxWait
+				if deadline, ok := ctx.Deadline(); ok && (maxWait <= 0 || deadline.Sub(now) < maxWait) {
+					maxWait = deadline.Sub(now)
+				}
+			}
+			if delay > maxWait {
+				reservation.CancelAt(now)
+				release()
+				return 0, l.reject("rate", delay)
+			}
+
+			l.mu.Lock()
+			l.queued++
+			l.mu.Unlock()
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				reservation.Cancel()
+				release()
+				return 0, ctx.Err()
+			}
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.roll(l.now())
+	l.byMinute++
+	l.total++
+	l.waited += delay
+	return delay, nil
+}
+
+// shared informa se a cota mensal é contada no Counter compartilhado
+func (l *Limiter) shared() bool {
+	return l.config.Counter != nil && l.config.MonthlyQuota > 0
+}
+
+// reserveMonthly conta a chamada na cota do mês, recusando-a quando a cota acabou. A
+// reserva vem antes da espera por um token para que chamadas simultâneas não passem
+// juntas da cota. Informa se a chamada foi contada e precisa ser devolvida caso não
+// aconteça.
+func (l *Limiter) reserveMonthly(ctx context.Context, now time.Time) (bool, error) {
+	if l.shared() {
+		return l.reserveShared(ctx, now)
+	}
+
+	l.mu.Lock()
+	l.roll(now)
+	if l.config.MonthlyQuota <= 0 || l.byMonth < l.config.MonthlyQuota {
+		l.byMonth++
+		l.mu.Unlock()
+		return true, nil
+	}
+	nextMonth := l.month.AddDate(0, 1, 0)
+	l.mu.Unlock()
+	return false, l.reject("monthly-quota", nextMonth.Sub(now))
+}
+
+// reserveShared reserva a chamada no Counter com um INCR atômico e a devolve quando o
+// total passa da cota, de modo que réplicas simultâneas nunca passem juntas dela. Com
+// o Counter indisponível, a chamada segue sem ser contada.
+func (l *Limiter) reserveShared(ctx context.Context, now time.Time) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, counterTimeout)
+	defer cancel()
+	key, nextMonth := l.counterKey(now)
+
+	used, err := l.config.Counter.IncrBy(ctx, key, 1, nextMonth)
+	if err != nil {
+		log.Printf("Contador da cota mensal de %s indisponível, chamada não contada: %v", l.name, err)
+		return false, nil
+	}
+	exceeded := used > int64(l.config.MonthlyQuota)
+	if exceeded {
+		if _, err := l.config.Counter.IncrBy(ctx, key, -1, nextMonth); err == nil {
+			used--
+		}
+	}
+
+	l.mu.Lock()
+	l.roll(now)
+	l.byMonth = int(used)
+	l.mu.Unlock()
+
+	if exceeded {
+		return false, l.reject("monthly-quota", nextMonth.Sub(now))
+	}
+	return true, nil
+}
+
+// releaseMonthly devolve a reserva de uma chamada que não aconteceu. No Counter, a
+// devolução não depende do contexto da chamada, que pode ter terminado.
+func (l *Limiter) releaseMonthly(ctx context.Context, now time.Time) {
+	if l.shared() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), counterTimeout)
+		defer cancel()
+		key, nextMonth := l.counterKey(now)
+		if used, err := l.config.Counter.IncrBy(ctx, key, -1, nextMonth); err == nil {
+			l.mu.Lock()
+			l.byMonth = int(used)
+			l.mu.Unlock()
+		}
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.byMonth > 0 {
+		l.byMonth--
+	}
+}
+
+// counterKey retorna a chave do contador do mês (UTC) de now e o início do mês seguinte,
+// quando o contador vence
+func (l *Limiter) counterKey(now time.Time) (string, time.Time) {
+	now = now.UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return fmt.Sprintf("%squota:%s:%s", l.config.KeyPrefix, l.name, month.Format("2006-01")), month.AddDate(0, 1, 0)
+}
+
+func (l *Limiter) reject(reason string, retryAfter time.Duration) error {
+	l.mu.Lock()
+	l.rejected++
+	l.mu.Unlock()
+	return &LimitedError{Provider: l.name, Reason: reason, RetryAfter: retryAfter}
+}
+
+// roll zera os contadores quando o minuto ou o mês mudam; chamada com mu travado
+func (l *Limiter) roll(now time.Time) {
+	now = now.UTC()
+	if minute := now.Truncate(time.Minute); !minute.Equal(l.minute) {
+		l.minute, l.byMinute = minute, 0
+	}
+	if month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC); !month.Equal(l.month) {
+		l.month, l.byMonth = month, 0
+	}
+}
+
+// Usage retorna o consumo das cotas
+func (l *Limiter) Usage() Usage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.roll(l.now())
+
+	usage := Usage{
+		Scope:          "process",
+		CountingSince:  l.month,
+		PerMinuteLimit: l.config.PerMinute,
+		UsedThisMinute: l.byMinute,
+		MonthlyQuota:   l.config.MonthlyQuota,
+		UsedThisMonth:  l.byMonth,
+		Total:          l.total,
+		Queued:         l.queued,
+		Rejected:       l.rejected,
+	}
+	if l.shared() {
+		usage.Scope = "shared"
+	} else if l.started.After(usage.CountingSince) {
+		usage.CountingSince = l.started
+	}
+	if l.config.MonthlyQuota > 0 {
+		remaining := max(l.config.MonthlyQuota-l.byMonth, 0)
+		usage.MonthlyRemaining = &remaining
+	}
+	if l.total > 0 {
+		usage.AvgWaitMs = float64(l.waited.Milliseconds()) / float64(l.total)
+	}
+	return usage
+}
+
+// Transport envolve next com o limitador. A espera na fila gera o evento "rate-limit-wait"
+// e a recusa, o evento "rate-limited", no span da chamada.
+func (l *Limiter) Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{limiter: l, next: next}
+}
+
+type transport struct {
+	limiter *Limiter
+	next    http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	span := trace.SpanFromContext(req.Context())
+	waited, err := t.limiter.Acquire(req.Context())
+	if err != nil {
+		var limited *LimitedError
+		if errors.As(err, &limited) {
+			span.AddEvent("rate-limited", trace.WithAttributes(
+				attribute.String("ratelimit.provider", limited.Provider),
+				attribute.String("ratelimit.reason", limited.Reason),
+				attribute.Int64("ratelimit.retry_after_ms", limited.RetryAfter.Milliseconds()),
+			))
+		}
+		return nil, err
+	}
+	if waited > 0 {
+		span.AddEvent("rate-limit-wait", trace.WithAttributes(
+			attribute.String("ratelimit.provider", t.limiter.name),
+			attribute.Int64("ratelimit.wait_ms", waited.Milliseconds()),
+		))
+	}
+	return t.next.RoundTrip(req)
+}
+
+// Registry reúne os limitadores dos provedores, criados sob demanda
+type Registry struct {
+	config func(name string) Config
+
+	mu       sync.Mutex
+	limiters map[string]*Limiter
+}
+
+// NewRegistry cria um registro cujos limitadores usam a configuração retornada por config
+func NewRegistry(config func(name string) Config) *Registry {
+	return &Registry{config: config, limiters: make(map[string]*Limiter)}
+}
+
+// Get retorna o limitador do provedor, criando-o na primeira chamada
+func (r *Registry) Get(name string) *Limiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.limiters[name]
+	if !ok {
+		l = New(name, r.config(name))
+		r.limiters[name] = l
+	}
+	return l
+}
+
+// Usage retorna o consumo de cada provedor
+func (r *Registry) Usage() map[string]Usage {
+	if r == nil {
+		return map[string]Usage{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage := make(map[string]Usage, len(r.limiters))
+	for name, l := range r.limiters {
+		usage[name] = l.Usage()
+	}
+	return usage
+}
@@ -0,0 +1,156 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiterFailFast(t *testing.T) {
+	l := New("weatherapi", Config{PerMinute: 60, Burst: 2})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := l.Acquire(ctx); err != nil {
+			t.Fatalf("Chamada %d dentro da rajada deveria passar: %v", i+1, err)
+		}
+	}
+	_, err := l.Acquire(ctx)
+	var limited *LimitedError
+	if !errors.As(err, &limited) || !errors.Is(err, ErrLimited) || limited.Reason != "rate" || limited.RetryAfter <= 0 {
+		t.Fatalf("Chamada além da rajada deveria ser recusada: %v", err)
+	}
+
+	usage := l.Usage()
+	if usage.UsedThisMinute != 2 || usage.UsedThisMonth != 2 || usage.Rejected != 1 {
+		t.Errorf("Consumo incorreto: %+v", usage)
+	}
+}
+
+func TestLimiterWait(t *testing.T) {
+	// 600 por minuto: um token a cada 100ms
+	l := New("weatherapi", Config{PerMinute: 600, Burst: 1, Wait: true, MaxWait: time.Second})
+	ctx := context.Background()
+	l.Acquire(ctx)
+
+	waited, err := l.Acquire(ctx)
+	if err != nil || waited <= 0 {
+		t.Fatalf("Chamada deveria esperar na fila: %s %v", waited, err)
+	}
+
+	// Um prazo menor que a espera recusa a chamada na hora
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx); !errors.Is(err, ErrLimited) {
+		t.Errorf("Espera maior que o prazo deveria ser recusada: %v", err)
+	}
+	if usage := l.Usage(); usage.Queued != 1 || usage.Total != 2 {
+		t.Errorf("Consumo incorreto: %+v", usage)
+	}
+}
+
+func TestLimiterMonthlyQuota(t *testing.T) {
+	now := time.Date(2025, 1, 31, 23, 59, 0, 0, time.UTC)
+	l := New("weatherapi", Config{MonthlyQuota: 2})
+	l.now = func() time.Time { return now }
+	l.started = now
+	ctx := context.Background()
+
+	l.Acquire(ctx)
+	l.Acquire(ctx)
+	_, err := l.Acquire(ctx)
+	var limited *LimitedError
+	if !errors.As(err, &limited) || limited.Reason != "monthly-quota" || limited.RetryAfter != time.Minute {
+		t.Fatalf("Cota mensal esgotada deveria recusar até a virada do mês: %v", err)
+	}
+	usage := l.Usage()
+	if usage.MonthlyRemaining == nil || *usage.MonthlyRemaining != 0 {
+		t.Errorf("Cota restante incorreta: %v", usage.MonthlyRemaining)
+	}
+	// A contagem do mês começou com o processo, não no início do mês
+	if usage.Scope != "process" || !usage.CountingSince.Equal(now) {
+		t.Errorf("Escopo da contagem incorreto: %s desde %s", usage.Scope, usage.CountingSince)
+	}
+
+	// Na virada do mês a cota é renovada
+	now = now.Add(time.Minute)
+	if _, err := l.Acquire(ctx); err != nil {
+		t.Errorf("Cota deveria ser renovada no novo mês: %v", err)
+	}
+	if since := l.Usage().CountingSince; !since.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Contagem do novo mês deveria começar na virada: %s", since)
+	}
+}
+
+// memoryCounter é um Counter em memória, compartilhado pelos limitadores do teste como
+// o Redis é pelas réplicas
+type memoryCounter struct {
+	mu       sync.Mutex
+	values   map[string]int64
+	expireAt map[string]time.Time
+	err      error
+}
+
+func (c *memoryCounter) IncrBy(ctx context.Context, key string, n int64, expireAt time.Time) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return 0, c.err
+	}
+	c.values[key] += n
+	c.expireAt[key] = expireAt
+	return c.values[key], nil
+}
+
+func TestLimiterSharedMonthlyQuota(t *testing.T) {
+	now := time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC)
+	counter := &memoryCounter{values: map[string]int64{}, expireAt: map[string]time.Time{}}
+	config := Config{MonthlyQuota: 3, Counter: counter, KeyPrefix: "cep-weather:"}
+
+	// Duas réplicas dividem a mesma cota
+	replicas := []*Limiter{New("weatherapi", config), New("weatherapi", config)}
+	for _, l := range replicas {
+		l.now = func() time.Time { return now }
+	}
+	ctx := context.Background()
+	for i, l := range []*Limiter{replicas[0], replicas[1], replicas[0]} {
+		if _, err := l.Acquire(ctx); err != nil {
+			t.Fatalf("Chamada %d dentro da cota deveria passar: %v", i+1, err)
+		}
+	}
+	for i, l := range replicas {
+		if _, err := l.Acquire(ctx); !errors.Is(err, ErrLimited) {
+			t.Errorf("Réplica %d deveria recusar com a cota esgotada: %v", i+1, err)
+		}
+	}
+
+	key := "cep-weather:quota:weatherapi:2025-01"
+	if counter.values[key] != 3 || !counter.expireAt[key].Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Contador incorreto: %v vencendo em %v", counter.values, counter.expireAt)
+	}
+	usage := replicas[1].Usage()
+	if usage.Scope != "shared" || usage.UsedThisMonth != 3 || *usage.MonthlyRemaining != 0 {
+		t.Errorf("Consumo incorreto: %+v", usage)
+	}
+
+	// Com o contador indisponível, a chamada segue sem ser contada
+	counter.err = errors.New("connection refused")
+	if _, err := replicas[0].Acquire(ctx); err != nil {
+		t.Errorf("Contador indisponível não deveria recusar a chamada: %v", err)
+	}
+
+	// Uma chamada que não acontece devolve a reserva
+	counter.err = nil
+	now = now.Add(time.Hour)
+	l := New("weatherapi", Config{MonthlyQuota: 3, Counter: counter, PerMinute: 1, Burst: 1})
+	l.now = func() time.Time { return now }
+	l.Acquire(ctx)
+	if _, err := l.Acquire(ctx); !errors.Is(err, ErrLimited) {
+		t.Fatalf("Chamada além do limite por minuto deveria ser recusada: %v", err)
+	}
+	if used := counter.values["quota:weatherapi:2025-02"]; used != 1 {
+		t.Errorf("Recusa pelo limite por minuto deveria devolver a reserva: %d", used)
+	}
+}
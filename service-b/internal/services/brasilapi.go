@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"service-b/internal/models"
+)
+
+const brasilAPIURL = "https://brasilapi.com.br/api/cep/v2/%s"
+
+// BrasilAPIProvider consulta a BrasilAPI
+type BrasilAPIProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewBrasilAPIProvider cria o provedor BrasilAPI a partir de uma URL com o placeholder do CEP
+func NewBrasilAPIProvider(url string, client *http.Client) *BrasilAPIProvider {
+	return &BrasilAPIProvider{url: url, client: client}
+}
+
+// Name retorna o nome do provedor
+func (p *BrasilAPIProvider) Name() string {
+	return "brasilapi"
+}
+
+// Lookup busca o CEP na BrasilAPI
+func (p *BrasilAPIProvider) Lookup(ctx context.Context, cep string) (*models.Address, error) {
+	url := fmt.Sprintf(p.url, cep)
+	log.Printf("Consultando CEP: %s", url)
+
+	var brasilAPIResp models.BrasilAPIResponse
+	status, err := getJSON(ctx, p.client, url, &brasilAPIResp)
+	if err != nil {
+		log.Printf("Erro ao consultar BrasilAPI: %v", err)
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		log.Printf("BrasilAPI retornou status code: %d", status)
+		return nil, cepStatusError(p.Name(), status)
+	}
+
+	// A BrasilAPI não informa código IBGE nem DDD, mas costuma informar as coordenadas
+	return &models.Address{
+		Cep:         brasilAPIResp.Cep,
+		Logradouro:  brasilAPIResp.Street,
+		Bairro:      brasilAPIResp.Neighborhood,
+		Localidade:  brasilAPIResp.City,
+		Uf:          brasilAPIResp.State,
+		Coordinates: parseBrasilAPICoordinates(brasilAPIResp),
+		Provider:    p.Name(),
+	}, nil
+}
+
+// parseBrasilAPICoordinates converte as coordenadas, que a BrasilAPI envia como texto
+// e omite para parte dos CEPs
+func parseBrasilAPICoordinates(resp models.BrasilAPIResponse) *models.Coordinates {
+	lat, errLat := strconv.ParseFloat(resp.Location.Coordinates.Latitude, 64)
+	lon, errLon := strconv.ParseFloat(resp.Location.Coordinates.Longitude, 64)
+	if errLat != nil || errLon != nil {
+		return nil
+	}
+	return &models.Coordinates{Lat: lat, Lon: lon}
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"service-b/internal/cache"
+	"service-b/internal/models"
+)
+
+// managedCaches retorna os caches ativos do serviço
+func (s *WeatherService) managedCaches() []cache.Managed {
+	var caches []cache.Managed
+	if s.cepCache != nil {
+		caches = append(caches, s.cepCache)
+	}
+	if s.notFoundCache != nil {
+		caches = append(caches, s.notFoundCache)
+	}
+	if s.temperatureCache != nil {
+		caches = append(caches, s.temperatureCache.entries)
+	}
+	return caches
+}
+
+// InspectCache retorna as entradas em cache de um CEP (endereço, registro de CEP
+// inexistente e a temperatura da sua localidade) ou de uma cidade
+func (s *WeatherService) InspectCache(ctx context.Context, cep, city string) ([]cache.Entry, error) {
+	var entries []cache.Entry
+
+	if cep != "" && s.cepCache != nil {
+		entry, ok, err := s.cepCache.Inspect(ctx, cep)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			entries = append(entries, *entry)
+
+			// Com o endereço em cache, a leitura de temperatura da sua localidade também
+			if address := entry.Value.(models.Address); s.temperatureCache != nil {
+				weather, ok, err := s.temperatureCache.entries.Inspect(ctx, temperatureKey(s.weatherQuery(&address)))
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					entries = append(entries, *weather)
+				}
+			}
+		}
+	}
+
+	if cep != "" && s.notFoundCache != nil {
+		entry, ok, err := s.notFoundCache.Inspect(ctx, cep)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			entries = append(entries, *entry)
+		}
+	}
+
+	if city != "" {
+		for _, c := range s.managedCaches() {
+			all, err := c.Entries(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range all {
+				if entryCity(entry) != "" && normalizePlace(entryCity(entry)) == normalizePlace(city) {
+					entries = append(entries, entry)
+				}
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// entryCity retorna a cidade de uma entrada de endereço ou de temperatura
+func entryCity(entry cache.Entry) string {
+	switch value := entry.Value.(type) {
+	case models.Address:
+		return value.Localidade
+	case temperatureEntry:
+		return value.City
+	}
+	return ""
+}
+
+// PurgeCache remove de um cache (ou de todos, com name vazio) a chave informada ou,
+// sem chave, as chaves que começam com prefix. Retorna quantas entradas saíram.
+func (s *WeatherService) PurgeCache(ctx context.Context, name, key, prefix string) (int, error) {
+	caches, err := s.selectCaches(name)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, c := range caches {
+		if key != "" {
+			ok, err := c.Remove(ctx, key)
+			if err != nil {
+				return removed, err
+			}
+			if ok {
+				removed++
+			}
+			continue
+		}
+
+		n, err := c.PurgePrefix(ctx, prefix)
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// FlushCache esvazia todos os caches do serviço
+func (s *WeatherService) FlushCache(ctx context.Context) (int, error) {
+	return s.PurgeCache(ctx, "", "", "")
+}
+
+// CacheReport resume o estado de cada cache: tamanho, taxa de acerto e idades
+func (s *WeatherService) CacheReport(ctx context.Context) (map[string]cache.Report, error) {
+	reports := map[string]cache.Report{}
+	for _, c := range s.managedCaches() {
+		report, err := c.Report(ctx)
+		if err != nil {
+			return nil, err
+		}
+		reports[c.Name()] = report
+	}
+	return reports, nil
+}
+
+// ErrUnknownCache indica um nome de cache inexistente ou desativado
+var ErrUnknownCache = errors.New("unknown cache")
+
+// selectCaches retorna o cache de nome informado, ou todos quando o nome é vazio
+func (s *WeatherService) selectCaches(name string) ([]cache.Managed, error) {
+	caches := s.managedCaches()
+	if name == "" {
+		return caches, nil
+	}
+	for _, c := range caches {
+		if c.Name() == name {
+			return []cache.Managed{c}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownCache, name)
+}
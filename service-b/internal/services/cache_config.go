@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"service-b/internal/cache"
+)
+
+// cacheConfig reúne a configuração comum aos caches do serviço, lida de CACHE_BACKEND,
+// REDIS_URL, CACHE_DISK_*, CACHE_KEY_PREFIX e CACHE_FORMAT
+type cacheConfig struct {
+	redis  *cache.Redis // Compartilhado pelos caches quando CACHE_BACKEND=redis
+	disk   *cache.Disk  // Compartilhado pelos caches de CEP quando CACHE_BACKEND=disk
+	prefix string
+	codec  cache.Codec
+}
+
+func newCacheConfig() (*cacheConfig, error) {
+	codec, err := cache.NewCodec(os.Getenv("CACHE_FORMAT"))
+	if err != nil {
+		return nil, err
+	}
+	config := &cacheConfig{
+		prefix: envOrDefault("CACHE_KEY_PREFIX", "cep-weather:"),
+		codec:  codec,
+	}
+
+	switch backend := strings.ToLower(envOrDefault("CACHE_BACKEND", "memory")); backend {
+	case "memory":
+	case "redis":
+		redis, err := cache.NewRedis(envOrDefault("REDIS_URL", "redis://localhost:6379/0"))
+		if err != nil {
+			return nil, err
+		}
+		// Um Redis indisponível não impede a inicialização: as consultas seguem sem cache
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := redis.Ping(ctx); err != nil {
+			log.Printf("Redis indisponível, as consultas seguirão sem cache até ele voltar: %v", err)
+		}
+		config.redis = redis
+	case "disk":
+		// Só os caches de CEP vão para o disco; as leituras de temperatura envelhecem
+		// em minutos e continuam em memória
+		disk, err := cache.NewDisk(envOrDefault("CACHE_DISK_PATH", "cep-cache.db"), envInt("CACHE_DISK_MAX_ENTRIES", 100000))
+		if err != nil {
+			return nil, fmt.Errorf("opening disk cache: %w", err)
+		}
+		if interval := envDuration("CACHE_DISK_COMPACT_INTERVAL", time.Hour); interval > 0 {
+			go disk.RunCompaction(context.Background(), interval)
+		}
+		log.Printf("Cache de CEPs em disco com %d entradas", disk.Stats().Size)
+		config.disk = disk
+	default:
+		return nil, fmt.Errorf("unknown cache backend: %s", backend)
+	}
+
+	log.Printf("Cache: backend %s, formato %s, prefixo %q", config.backendName(), codec.Name(), config.prefix)
+	return config, nil
+}
+
+// backend retorna o backend de um cache; em memória, com a capacidade informada
+func (c *cacheConfig) backend(size int) cache.Backend {
+	if c.redis != nil {
+		return c.redis
+	}
+	return cache.NewMemory(size)
+}
+
+// persistentBackend retorna o backend dos caches de CEP, que vão para o disco quando
+// configurado e, fora isso, ficam no mesmo backend dos demais
+func (c *cacheConfig) persistentBackend(size int) cache.Backend {
+	if c.disk != nil {
+		return c.disk
+	}
+	return c.backend(size)
+}
+
+func (c *cacheConfig) backendName() string {
+	if c.redis != nil {
+		return "redis"
+	}
+	if c.disk != nil {
+		return "disk"
+	}
+	return "memory"
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"cep-weather-api/pkg/faults"
+	"service-b/internal/cache"
+	"service-b/internal/models"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestGetCityByCEPCache(t *testing.T) {
+	recorder := recordSpans(t)
+
+	provider := &fakeProvider{name: "viacep", city: "São Paulo"}
+	service := &WeatherService{
+		tracer:      otel.GetTracerProvider().Tracer("weather-service"),
+		cepProvider: provider,
+		cepCache:    cache.NewStore[models.Address]("cep", cache.NewMemory(10), "", cache.JSONCodec{}),
+		cepCacheTTL: time.Minute,
+	}
+
+	for i := 0; i < 2; i++ {
+		address, err := service.GetCityByCEP(context.Background(), "01001000")
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if address.Localidade != "São Paulo" {
+			t.Errorf("Cidade incorreta: %s", address.Localidade)
+		}
+	}
+	if provider.calls.Load() != 1 {
+		t.Errorf("Provedor deveria ser consultado uma vez, foi %d", provider.calls.Load())
+	}
+
+	var hits []bool
+	for _, span := range recorder.Ended() {
+		if span.Name() != "get-city-by-cep" {
+			continue
+		}
+		for _, attr := range span.Attributes() {
+			if attr.Key == "cache.hit" {
+				hits = append(hits, attr.Value.AsBool())
+			}
+		}
+	}
+	if len(hits) != 2 || hits[0] || !hits[1] {
+		t.Errorf("Atributo cache.hit incorreto: %v", hits)
+	}
+
+	stats := service.CacheStats()["cep"]
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Contadores incorretos: %+v", stats)
+	}
+}
+
+func TestGetCityByCEPNegativeCache(t *testing.T) {
+	recordSpans(t)
+
+	newService := func(provider CEPProvider) *WeatherService {
+		return &WeatherService{
+			tracer:        otel.GetTracerProvider().Tracer("weather-service"),
+			cepProvider:   provider,
+			cepCache:      cache.NewStore[models.Address]("cep", cache.NewMemory(10), "", cache.JSONCodec{}),
+			cepCacheTTL:   time.Minute,
+			notFoundCache: cache.NewStore[notFoundEntry]("cep-not-found", cache.NewMemory(10), "", cache.JSONCodec{}),
+			notFoundTTL:   time.Minute,
+		}
+	}
+
+	t.Run("CEP inexistente é lembrado", func(t *testing.T) {
+		provider := &fakeProvider{name: "viacep", err: ErrCEPNotFound}
+		service := newService(provider)
+
+		for i := 0; i < 3; i++ {
+			if _, err := service.GetCityByCEP(context.Background(), "99999999"); !errors.Is(err, ErrCEPNotFound) {
+				t.Fatalf("Erro incorreto: obtido %v, esperado %v", err, ErrCEPNotFound)
+			}
+		}
+		if provider.calls.Load() != 1 {
+			t.Errorf("Provedor deveria ser consultado uma vez, foi %d", provider.calls.Load())
+		}
+		if stats, ok := service.CacheStats()["cep-not-found"]; !ok || stats.Hits != 2 || stats.Misses != 1 {
+			t.Errorf("Contadores do cache cep-not-found incorretos: %+v", service.CacheStats())
+		}
+	})
+
+	t.Run("falha de rede não é lembrada", func(t *testing.T) {
+		provider := &fakeProvider{name: "viacep", err: errors.New("connection refused")}
+		service := newService(provider)
+
+		for i := 0; i < 2; i++ {
+			if _, err := service.GetCityByCEP(context.Background(), "01001000"); err == nil || errors.Is(err, ErrCEPNotFound) {
+				t.Fatalf("Esperado erro de rede, obtido %v", err)
+			}
+		}
+		if provider.calls.Load() != 2 {
+			t.Errorf("Provedor deveria ser consultado a cada requisição, foi %d", provider.calls.Load())
+		}
+	})
+
+	t.Run("CEP inexistente injetado não é lembrado", func(t *testing.T) {
+		injector := faults.NewInjector()
+		injector.SetRules([]faults.Rule{{ID: "simulate-cep-not-found", Provider: "offline", Status: http.StatusNotFound}})
+		provider, err := LoadCEPDataset(strings.NewReader(testDataset))
+		if err != nil {
+			t.Fatalf("Erro ao carregar base: %v", err)
+		}
+		provider.faults = injector
+		service := newService(provider)
+
+		if _, err := service.GetCityByCEP(context.Background(), "01001000"); !errors.Is(err, ErrCEPNotFound) {
+			t.Fatalf("Erro incorreto: obtido %v, esperado %v", err, ErrCEPNotFound)
+		}
+
+		// Removida a regra, o CEP volta a ser encontrado
+		injector.SetRules(nil)
+		address, err := service.GetCityByCEP(context.Background(), "01001000")
+		if err != nil {
+			t.Fatalf("CEP não deveria continuar inexistente após remover a regra: %v", err)
+		}
+		if address.Localidade != "São Paulo" {
+			t.Errorf("Cidade incorreta: %s", address.Localidade)
+		}
+	})
+}
+
+func TestCacheAdministration(t *testing.T) {
+	recordSpans(t)
+
+	ctx := context.Background()
+	service := &WeatherService{
+		tracer:           otel.GetTracerProvider().Tracer("weather-service"),
+		cepProvider:      &fakeProvider{name: "viacep", city: "São Paulo"},
+		coordinates:      &CoordinateTable{},
+		cepCache:         cache.NewStore[models.Address]("cep", cache.NewMemory(10), "", cache.JSONCodec{}),
+		cepCacheTTL:      time.Minute,
+		temperatureCache: newTemperatureCache(newMemoryTemperatureStore(), time.Minute, time.Minute, 0),
+	}
+
+	address, err := service.GetCityByCEP(ctx, "01001000")
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	service.temperatureCache.put(ctx, temperatureKey(service.weatherQuery(address)), address.Localidade, &models.WeatherReading{TempC: 25})
+
+	entries, err := service.InspectCache(ctx, "01001000", "")
+	if err != nil || len(entries) != 2 || entries[0].Cache != "cep" || entries[1].Cache != "weather" {
+		t.Errorf("Entradas do CEP incorretas: %+v %v", entries, err)
+	}
+	entries, err = service.InspectCache(ctx, "", "sao paulo")
+	if err != nil || len(entries) != 2 {
+		t.Errorf("Entradas da cidade incorretas: %+v %v", entries, err)
+	}
+
+	if _, err := service.PurgeCache(ctx, "correios", "01001000", ""); !errors.Is(err, ErrUnknownCache) {
+		t.Errorf("Erro incorreto: obtido %v, esperado %v", err, ErrUnknownCache)
+	}
+	if removed, err := service.PurgeCache(ctx, "cep", "01001000", ""); err != nil || removed != 1 {
+		t.Errorf("Remoção incorreta: %d %v", removed, err)
+	}
+	if removed, err := service.FlushCache(ctx); err != nil || removed != 1 {
+		t.Errorf("Esvaziamento incorreto: %d %v", removed, err)
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cep-weather-api/pkg/faults"
+	"service-b/internal/models"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CEPProviderChain consulta os provedores em ordem, passando para o próximo quando
+// um deles falha (erro de rede, status 5xx ou JSON inválido). Um CEP inexistente
+// é uma resposta definitiva e encerra a cadeia. Com hedging, o próximo provedor
+// também é consultado quando o atual demora mais que o atraso do Hedger.
+type CEPProviderChain struct {
+	providers []CEPProvider
+	tracer    trace.Tracer
+	hedger    *Hedger
+}
+
+// NewCEPProviderChain cria uma cadeia com os provedores na ordem informada
+func NewCEPProviderChain(providers ...CEPProvider) *CEPProviderChain {
+	return &CEPProviderChain{
+		providers: providers,
+		tracer:    otel.GetTracerProvider().Tracer("weather-service"),
+	}
+}
+
+// NewCEPProviderChainFromSpec cria a cadeia a partir de uma lista separada por vírgulas,
+// como "viacep,brasilapi,opencep". Uma lista vazia usa apenas o ViaCEP.
+func NewCEPProviderChainFromSpec(spec string, clients ClientFactory, injector *faults.Injector) (*CEPProviderChain, error) {
+	var providers []CEPProvider
+	for _, name := range strings.Split(spec, ",") {
+		if strings.TrimSpace(name) == "" && len(providers) > 0 {
+			continue
+		}
+		provider, err := NewCEPProvider(name, clients, injector)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	return NewCEPProviderChain(providers...), nil
+}
+
+// Name retorna os nomes dos provedores na ordem da cadeia
+func (c *CEPProviderChain) Name() string {
+	names := make([]string, len(c.providers))
+	for i, provider := range c.providers {
+		names[i] = provider.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// Lookup consulta os provedores em ordem e retorna a primeira resposta definitiva
+func (c *CEPProviderChain) Lookup(ctx context.Context, cep string) (*models.Address, error) {
+	attempts := make([]attemptFunc[*models.Address], len(c.providers))
+	for i, provider := range c.providers {
+		attempts[i] = func(ctx context.Context, hedged bool) (*models.Address, error) {
+			return c.attempt(ctx, i, hedged, provider, cep)
+		}
+	}
+
+	var delay func(int) time.Duration
+	if c.hedger != nil {
+		delay = func(i int) time.Duration { return c.hedger.Delay(c.providers[i].Name()) }
+	}
+
+	isNotFound := func(err error) bool { return errors.Is(err, ErrCEPNotFound) }
+	address, _, err := raceAttempts(ctx, attempts, delay, isNotFound)
+	if err != nil && !isNotFound(err) {
+		return nil, fmt.Errorf("all CEP providers failed: %w", err)
+	}
+	return address, err
+}
+
+// attempt executa uma tentativa em um span próprio
+func (c *CEPProviderChain) attempt(ctx context.Context, index int, hedged bool, provider CEPProvider, cep string) (*models.Address, error) {
+	ctx, span := c.tracer.Start(ctx, "cep-provider-attempt")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("cep.provider", provider.Name()),
+		attribute.Int("cep.attempt", index+1),
+		attribute.Bool("hedged", hedged),
+	)
+
+	start := time.Now()
+	address, err := provider.Lookup(ctx, cep)
+	switch {
+	case err == nil:
+		span.SetAttributes(attribute.String("cep.outcome", "found"))
+		if c.hedger != nil {
+			c.hedger.Observe(provider.Name(), time.Since(start))
+		}
+	case errors.Is(err, ErrCEPNotFound):
+		span.SetAttributes(attribute.String("cep.outcome", "not_found"))
+	case ctx.Err() != nil:
+		// Perdeu a corrida para outra tentativa ou a requisição foi cancelada
+		span.SetAttributes(attribute.String("cep.outcome", "cancelled"))
+	default:
+		log.Printf("Provedor de CEP %s falhou: %v", provider.Name(), err)
+		span.SetAttributes(attribute.String("cep.outcome", "error"))
+		span.RecordError(err)
+	}
+	return address, err
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCEPProviderChain(t *testing.T) {
+	t.Run("falha do primeiro provedor usa o próximo", func(t *testing.T) {
+		recorder := recordSpans(t)
+		failing := &fakeProvider{name: "viacep", err: errors.New("viacep returned status 503")}
+		backup := &fakeProvider{name: "brasilapi", city: "São Paulo"}
+
+		address, err := NewCEPProviderChain(failing, backup).Lookup(context.Background(), "01001000")
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if address.Provider != "brasilapi" {
+			t.Errorf("Provedor incorreto: obtido %s, esperado brasilapi", address.Provider)
+		}
+		if spans := recorder.Ended(); len(spans) != 2 {
+			t.Errorf("Número de spans incorreto: obtido %d, esperado 2", len(spans))
+		}
+	})
+
+	t.Run("CEP inexistente encerra a cadeia", func(t *testing.T) {
+		notFound := &fakeProvider{name: "viacep", err: ErrCEPNotFound}
+		backup := &fakeProvider{name: "brasilapi", city: "São Paulo"}
+
+		_, err := NewCEPProviderChain(notFound, backup).Lookup(context.Background(), "99999999")
+		if !errors.Is(err, ErrCEPNotFound) {
+			t.Errorf("Erro incorreto: obtido %v, esperado %v", err, ErrCEPNotFound)
+		}
+		if backup.calls.Load() != 0 {
+			t.Errorf("Provedor seguinte não deveria ser consultado")
+		}
+	})
+
+	t.Run("todos os provedores falham", func(t *testing.T) {
+		first := &fakeProvider{name: "viacep", err: errors.New("timeout")}
+		second := &fakeProvider{name: "opencep", err: errors.New("invalid character")}
+
+		_, err := NewCEPProviderChain(first, second).Lookup(context.Background(), "01001000")
+		if err == nil || errors.Is(err, ErrCEPNotFound) {
+			t.Errorf("Esperado erro de falha dos provedores, obtido %v", err)
+		}
+	})
+}
+
+func TestNewCEPProviderChainFromSpec(t *testing.T) {
+	chain, err := NewCEPProviderChainFromSpec("viacep, brasilapi,opencep", nil, nil)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if chain.Name() != "viacep,brasilapi,opencep" {
+		t.Errorf("Ordem incorreta: %s", chain.Name())
+	}
+
+	chain, err = NewCEPProviderChainFromSpec("", nil, nil)
+	if err != nil || chain.Name() != "viacep" {
+		t.Errorf("Cadeia padrão incorreta: %v %v", chain, err)
+	}
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"cep-weather-api/pkg/faults"
+	"service-b/internal/models"
+)
+
+// ErrCEPNotFound indica que o provedor respondeu que o CEP não existe
+var ErrCEPNotFound = errors.New("CEP not found")
+
+// CEPProvider resolve um CEP em um endereço normalizado
+type CEPProvider interface {
+	// Name identifica o provedor em logs, spans e respostas
+	Name() string
+	// Lookup busca o endereço do CEP, retornando ErrCEPNotFound quando ele não existe
+	Lookup(ctx context.Context, cep string) (*models.Address, error)
+}
+
+// NewCEPProvider cria o provedor de CEP correspondente ao nome informado. Os provedores
+// HTTP recebem as falhas injetadas pelo transport dos clientes; o offline, que não usa
+// a rede, as recebe de injector, que pode ser nil.
+func NewCEPProvider(name string, clients ClientFactory, injector *faults.Injector) (CEPProvider, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "viacep":
+		return NewViaCEPProvider(envOrDefault("VIACEP_URL", viaCEPURL), clients.client("viacep")), nil
+	case "brasilapi":
+		return NewBrasilAPIProvider(envOrDefault("BRASILAPI_URL", brasilAPIURL), clients.client("brasilapi")), nil
+	case "opencep":
+		return NewOpenCEPProvider(envOrDefault("OPENCEP_URL", openCEPURL), clients.client("opencep")), nil
+	case "offline":
+		provider, err := NewOfflineCEPProvider(os.Getenv("CEP_DATASET_FILE"))
+		if err != nil {
+			return nil, err
+		}
+		provider.faults = injector
+		return provider, nil
+	default:
+		return nil, fmt.Errorf("unknown CEP provider: %s", name)
+	}
+}
+
+// LegacyFaultRules retorna as regras de falha da configuração antiga: a variável
+// SIMULATE_CEP_NOT_FOUND=true continua aceita e equivale a uma regra que responde 404
+// em todos os provedores de CEP.
+func LegacyFaultRules() []faults.Rule {
+	if os.Getenv("SIMULATE_CEP_NOT_FOUND") != "true" {
+		return nil
+	}
+	log.Println("SIMULATE_CEP_NOT_FOUND está obsoleta; use FAULTS_FILE ou /admin/faults")
+	return []faults.Rule{{
+		ID:       "simulate-cep-not-found",
+		Provider: "viacep,brasilapi,opencep,offline",
+		Status:   http.StatusNotFound,
+	}}
+}
+
+// cepStatusError interpreta um status diferente de 200: 400 e 404 significam que o CEP
+// não existe, enquanto os demais indicam falha do provedor
+func cepStatusError(provider string, status int) error {
+	if status == http.StatusNotFound || status == http.StatusBadRequest {
+		return ErrCEPNotFound
+	}
+	return fmt.Errorf("%s returned status %d", provider, status)
+}
+
+// getJSON executa um GET e decodifica o corpo em dst quando o status é 200.
+// O status code é sempre retornado para que cada provedor interprete seus próprios erros.
+func getJSON(ctx context.Context, client *http.Client, url string, dst interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return resp.StatusCode, nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cep-weather-api/pkg/faults"
+)
+
+// mockCEPServer responde com o corpo informado para o CEP 01001000 e 404 para os demais
+func mockCEPServer(body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.URL.Path, "01001000") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+}
+
+func TestCEPProviders(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		provider func(url string) CEPProvider
+	}{
+		{
+			name: "viacep",
+			body: `{"cep":"01001-000","logradouro":"Praça da Sé","bairro":"Sé","localidade":"São Paulo","uf":"SP","ibge":"3550308","ddd":"11"}`,
+			provider: func(url string) CEPProvider {
+				return NewViaCEPProvider(url+"/ws/%s/json/", http.DefaultClient)
+			},
+		},
+		{
+			name: "brasilapi",
+			body: `{"cep":"01001000","state":"SP","city":"São Paulo","neighborhood":"Sé","street":"Praça da Sé","service":"viacep"}`,
+			provider: func(url string) CEPProvider {
+				return NewBrasilAPIProvider(url+"/api/cep/v2/%s", http.DefaultClient)
+			},
+		},
+		{
+			name: "opencep",
+			body: `{"cep":"01001-000","logradouro":"Praça da Sé","bairro":"Sé","localidade":"São Paulo","uf":"SP","ibge":"3550308"}`,
+			provider: func(url string) CEPProvider {
+				return NewOpenCEPProvider(url+"/v1/%s", http.DefaultClient)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := mockCEPServer(tt.body)
+			defer server.Close()
+
+			provider := tt.provider(server.URL)
+
+			address, err := provider.Lookup(context.Background(), "01001000")
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if address.Localidade != "São Paulo" || address.Uf != "SP" || address.Bairro != "Sé" {
+				t.Errorf("Endereço incorreto: %+v", address)
+			}
+			if address.Provider != tt.name {
+				t.Errorf("Provedor incorreto: obtido %s, esperado %s", address.Provider, tt.name)
+			}
+
+			if _, err := provider.Lookup(context.Background(), "99999999"); !errors.Is(err, ErrCEPNotFound) {
+				t.Errorf("Erro incorreto para CEP inexistente: %v", err)
+			}
+		})
+	}
+}
+
+func TestViaCEPErro(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"erro": true}`))
+	}))
+	defer server.Close()
+
+	provider := NewViaCEPProvider(server.URL+"/ws/%s/json/", http.DefaultClient)
+	if _, err := provider.Lookup(context.Background(), "99999999"); !errors.Is(err, ErrCEPNotFound) {
+		t.Errorf("Erro incorreto: obtido %v, esperado %v", err, ErrCEPNotFound)
+	}
+}
+
+func TestNewCEPProvider(t *testing.T) {
+	for _, name := range []string{"", "viacep", "BrasilAPI", "opencep"} {
+		if _, err := NewCEPProvider(name, nil, nil); err != nil {
+			t.Errorf("Erro inesperado para %q: %v", name, err)
+		}
+	}
+	if _, err := NewCEPProvider("correios", nil, nil); err == nil {
+		t.Errorf("Esperado erro para provedor desconhecido")
+	}
+}
+
+func TestLegacyFaultRules(t *testing.T) {
+	if rules := LegacyFaultRules(); rules != nil {
+		t.Fatalf("Sem SIMULATE_CEP_NOT_FOUND não deveria haver regras: %+v", rules)
+	}
+
+	t.Setenv("SIMULATE_CEP_NOT_FOUND", "true")
+	injector, err := faults.FromEnv(LegacyFaultRules()...)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	for _, provider := range []string{"viacep", "brasilapi", "opencep", "offline"} {
+		if status, _ := injector.Check(context.Background(), provider); status != http.StatusNotFound {
+			t.Errorf("A regra deveria afetar o provedor %s, obtido status %d", provider, status)
+		}
+	}
+	if status, _ := injector.Check(context.Background(), "weatherapi"); status != 0 {
+		t.Errorf("A regra deveria afetar apenas os provedores de CEP")
+	}
+}
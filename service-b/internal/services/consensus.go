@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"service-b/internal/models"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// consensusReading consulta todos os provedores de clima em paralelo, sob um único prazo,
+// e retorna a leitura mediana acompanhada do mínimo, do máximo e das leituras individuais.
+// Cada provedor é consultado em um span próprio, irmão dos demais sob get-temperature.
+func (s *WeatherService) consensusReading(ctx context.Context, query models.WeatherQuery) (*models.WeatherReading, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.consensusTimeout)
+	defer cancel()
+
+	results := make([]*models.WeatherReading, len(s.weatherProviders))
+	var wg sync.WaitGroup
+	for i, provider := range s.weatherProviders {
+		wg.Add(1)
+		go func(i int, provider WeatherProvider) {
+			defer wg.Done()
+
+			ctx, span := s.tracer.Start(ctx, "weather-provider-call")
+			defer span.End()
+			span.SetAttributes(attribute.String("weather.provider", provider.Name()))
+
+			reading, err := s.fetchReading(ctx, span, provider, query)
+			if err != nil {
+				log.Printf("Provedor de clima %s falhou no consenso: %v", provider.Name(), err)
+				span.RecordError(err)
+				return
+			}
+			span.SetAttributes(attribute.Float64("temperature_c", reading.TempC))
+			results[i] = reading
+		}(i, provider)
+	}
+	wg.Wait()
+
+	consensus := &models.WeatherConsensus{}
+	var readings []*models.WeatherReading
+	for i, reading := range results {
+		if reading == nil {
+			consensus.Failed = append(consensus.Failed, s.weatherProviders[i].Name())
+			continue
+		}
+		readings = append(readings, reading)
+		consensus.Readings = append(consensus.Readings, models.ProviderReading{
+			Provider:           reading.Provider,
+			TempC:              reading.TempC,
+			LocationConfidence: reading.LocationConfidence,
+		})
+	}
+
+	if len(readings) == 0 {
+		return nil, fmt.Errorf("no weather provider answered within %s", s.consensusTimeout)
+	}
+
+	sort.Slice(readings, func(i, j int) bool { return readings[i].TempC < readings[j].TempC })
+	consensus.MinC = readings[0].TempC
+	consensus.MaxC = readings[len(readings)-1].TempC
+	consensus.SpreadC = consensus.MaxC - consensus.MinC
+	consensus.MedianC = median(readings)
+
+	// A leitura mediana (a inferior, com número par de leituras) representa o consenso
+	result := *readings[(len(readings)-1)/2]
+	result.TempC = consensus.MedianC
+	result.LocationConfidence = lowestConfidence(readings)
+	result.Consensus = consensus
+	return &result, nil
+}
+
+// median calcula a mediana de leituras já ordenadas por temperatura
+func median(readings []*models.WeatherReading) float64 {
+	n := len(readings)
+	if n%2 == 1 {
+		return readings[n/2].TempC
+	}
+	return (readings[n/2-1].TempC + readings[n/2].TempC) / 2
+}
+
+// lowestConfidence retorna a menor confiança de localidade entre as leituras
+func lowestConfidence(readings []*models.WeatherReading) string {
+	confidence := LocationConfidenceHigh
+	for _, reading := range readings {
+		switch reading.LocationConfidence {
+		case LocationConfidenceLow:
+			return LocationConfidenceLow
+		case LocationConfidenceUnverified:
+			confidence = LocationConfidenceUnverified
+		}
+	}
+	return confidence
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"service-b/internal/models"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestConsensusReading(t *testing.T) {
+	recorder := recordSpans(t)
+
+	service := &WeatherService{
+		tracer: otel.Tracer("test"),
+		weatherProviders: []WeatherProvider{
+			&fakeProvider{name: "weatherapi", tempC: 21},
+			&fakeProvider{name: "openmeteo", tempC: 19},
+			&fakeProvider{name: "fixture", tempC: 26},
+			&fakeProvider{name: "lento", tempC: 40, delay: time.Second},
+			&fakeProvider{name: "quebrado", err: errors.New("status 500")},
+		},
+		consensus:        true,
+		consensusTimeout: 100 * time.Millisecond,
+	}
+
+	query := models.WeatherQuery{City: "São Paulo", Uf: "SP", Coordinates: &models.Coordinates{Lat: -23.5, Lon: -46.6}}
+	reading, err := service.consensusReading(context.Background(), query)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+
+	if reading.TempC != 21 || reading.Consensus.MedianC != 21 {
+		t.Errorf("Mediana incorreta: %+v", reading.Consensus)
+	}
+	if reading.Consensus.MinC != 19 || reading.Consensus.MaxC != 26 || reading.Consensus.SpreadC != 7 {
+		t.Errorf("Faixa incorreta: %+v", reading.Consensus)
+	}
+	if len(reading.Consensus.Readings) != 3 || len(reading.Consensus.Failed) != 2 {
+		t.Errorf("Leituras incorretas: %+v", reading.Consensus)
+	}
+	if spans := recorder.Ended(); len(spans) != 5 {
+		t.Errorf("Esperado um span por provedor, obtido %d", len(spans))
+	}
+}
+
+func TestConsensusReadingEvenCount(t *testing.T) {
+	service := &WeatherService{
+		tracer: otel.Tracer("test"),
+		weatherProviders: []WeatherProvider{
+			&fakeProvider{name: "a", tempC: 20},
+			&fakeProvider{name: "b", tempC: 23},
+		},
+		consensus:        true,
+		consensusTimeout: time.Second,
+	}
+
+	reading, err := service.consensusReading(context.Background(), models.WeatherQuery{Coordinates: &models.Coordinates{}})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if reading.TempC != 21.5 {
+		t.Errorf("Mediana incorreta: obtido %v, esperado 21.5", reading.TempC)
+	}
+}
+
+func TestConsensusReadingAllFailed(t *testing.T) {
+	service := &WeatherService{
+		tracer:           otel.Tracer("test"),
+		weatherProviders: []WeatherProvider{&fakeProvider{name: "a", err: errors.New("timeout")}},
+		consensus:        true,
+		consensusTimeout: time.Second,
+	}
+
+	if _, err := service.consensusReading(context.Background(), models.WeatherQuery{}); err == nil {
+		t.Errorf("Esperado erro quando nenhum provedor responde")
+	}
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"service-b/internal/models"
+)
+
+// Tabela embutida com as coordenadas das capitais e de algumas cidades de nome ambíguo.
+// Uma tabela completa de municípios pode ser carregada com IBGE_COORDINATES_FILE.
+//
+//go:embed data/municipios.csv
+var embeddedMunicipios []byte
+
+// CoordinateTable associa códigos IBGE de municípios às suas coordenadas
+type CoordinateTable struct {
+	byIBGE map[string]models.Coordinates
+}
+
+// NewCoordinateTable carrega a tabela embutida e, se informado, o arquivo CSV adicional.
+// O arquivo deve ter cabeçalho com as colunas codigo_ibge, latitude e longitude.
+func NewCoordinateTable(path string) (*CoordinateTable, error) {
+	table := &CoordinateTable{byIBGE: make(map[string]models.Coordinates)}
+	if err := table.Load(bytes.NewReader(embeddedMunicipios)); err != nil {
+		return nil, err
+	}
+
+	if path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		if err := table.Load(file); err != nil {
+			return nil, fmt.Errorf("error loading %s: %w", path, err)
+		}
+	}
+	return table, nil
+}
+
+// Load lê um CSV de municípios, sobrescrevendo entradas já existentes
+func (t *CoordinateTable) Load(r io.Reader) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	ibgeCol, okIBGE := columns["codigo_ibge"]
+	latCol, okLat := columns["latitude"]
+	lonCol, okLon := columns["longitude"]
+	if !okIBGE || !okLat || !okLon {
+		return fmt.Errorf("missing codigo_ibge, latitude or longitude column")
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		lat, err := strconv.ParseFloat(strings.TrimSpace(record[latCol]), 64)
+		if err != nil {
+			return fmt.Errorf("invalid latitude for %s: %w", record[ibgeCol], err)
+		}
+		lon, err := strconv.ParseFloat(strings.TrimSpace(record[lonCol]), 64)
+		if err != nil {
+			return fmt.Errorf("invalid longitude for %s: %w", record[ibgeCol], err)
+		}
+		t.byIBGE[strings.TrimSpace(record[ibgeCol])] = models.Coordinates{Lat: lat, Lon: lon}
+	}
+}
+
+// Lookup retorna as coordenadas do município pelo código IBGE
+func (t *CoordinateTable) Lookup(ibge string) (models.Coordinates, bool) {
+	coords, ok := t.byIBGE[ibge]
+	return coords, ok
+}
+
+// Len retorna o número de municípios conhecidos
+func (t *CoordinateTable) Len() int {
+	return len(t.byIBGE)
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"service-b/internal/models"
+)
+
+func TestCoordinateTable(t *testing.T) {
+	table, err := NewCoordinateTable("")
+	if err != nil {
+		t.Fatalf("Erro ao carregar tabela embutida: %v", err)
+	}
+
+	// Santa Maria/RS não pode ser confundida com as homônimas de outros estados
+	coords, ok := table.Lookup("4316907")
+	if !ok || coords.Lat > -29 || coords.Lon > -53 {
+		t.Errorf("Coordenadas incorretas para Santa Maria/RS: %+v", coords)
+	}
+
+	if _, ok := table.Lookup("0000000"); ok {
+		t.Errorf("Código IBGE inexistente não deveria ser encontrado")
+	}
+}
+
+func TestCoordinateTableFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "municipios.csv")
+	content := "codigo_ibge,nome,latitude,longitude,capital\n2903201,Bom Jesus da Lapa,-13.2506,-43.4108,0\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := NewCoordinateTable(path)
+	if err != nil {
+		t.Fatalf("Erro ao carregar arquivo: %v", err)
+	}
+	if _, ok := table.Lookup("2903201"); !ok {
+		t.Errorf("Município do arquivo não encontrado")
+	}
+	if _, ok := table.Lookup("3550308"); !ok {
+		t.Errorf("Tabela embutida deveria continuar disponível")
+	}
+
+	if err := table.Load(strings.NewReader("codigo,nome\n1,x\n")); err == nil {
+		t.Errorf("Esperado erro para arquivo sem colunas de coordenadas")
+	}
+}
+
+func TestWeatherQuery(t *testing.T) {
+	table, err := NewCoordinateTable("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	service := &WeatherService{coordinates: table}
+
+	tests := []struct {
+		name    string
+		address models.Address
+		lat     float64
+		byName  bool
+	}{
+		{
+			name:    "coordenadas do provedor",
+			address: models.Address{Localidade: "São Paulo", Ibge: "3550308", Coordinates: &models.Coordinates{Lat: -23.5, Lon: -46.6}},
+			lat:     -23.5,
+		},
+		{
+			name:    "coordenadas pelo código IBGE",
+			address: models.Address{Localidade: "Santa Maria", Uf: "RS", Ibge: "4316907"},
+			lat:     -29.6842,
+		},
+		{
+			name:    "consulta pelo nome",
+			address: models.Address{Localidade: "Bom Jesus", Uf: "PI", Ibge: "2201903"},
+			byName:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := service.weatherQuery(&tt.address)
+			if tt.byName {
+				if query.Coordinates != nil {
+					t.Errorf("Esperada consulta pelo nome, obtido %+v", query.Coordinates)
+				}
+				return
+			}
+			if query.Coordinates == nil || query.Coordinates.Lat != tt.lat {
+				t.Errorf("Coordenadas incorretas: %+v", query.Coordinates)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// envOrDefault retorna o valor da variável de ambiente ou o padrão informado
+func envOrDefault(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// envDuration lê uma duração (ex.: "500ms", "3s") da variável de ambiente,
+// usando o padrão quando ela está vazia ou é inválida
+func envDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("Valor inválido para %s (%q), usando %s", key, v, def)
+		return def
+	}
+	return d
+}
+
+// envInt lê um inteiro da variável de ambiente, usando o padrão quando ela está
+// vazia ou é inválida
+func envInt(key string, def int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("Valor inválido para %s (%q), usando %d", key, v, def)
+		return def
+	}
+	return n
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"service-b/internal/models"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// fakeProvider é o provedor de CEP e de clima dos testes. Cada chamada é contada,
+// aguarda o atraso e o gate (ou o fim do contexto) e então responde com err ou
+// com a cidade e a temperatura configuradas.
+type fakeProvider struct {
+	name      string                   // Padrão "viacep"
+	city      string                   // Cidade de todo CEP; padrão "São Paulo"
+	cities    map[string]string        // Cidade por CEP; CEPs fora da tabela não existem
+	tempC     float64                  // Alterada durante o teste com setTempC
+	plain     *models.ResolvedLocation // Localidade informada nas consultas pelo nome
+	qualified *models.ResolvedLocation // Localidade informada nas consultas qualificadas
+	delay     time.Duration
+	gate      chan struct{} // Com gate, cada chamada aguarda o canal ser fechado
+	err       error
+
+	calls   atomic.Int64
+	mu      sync.Mutex
+	queries []models.WeatherQuery
+}
+
+func (p *fakeProvider) Name() string {
+	if p.name == "" {
+		return "viacep"
+	}
+	return p.name
+}
+
+func (p *fakeProvider) Lookup(ctx context.Context, cep string) (*models.Address, error) {
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+	city := p.city
+	if p.cities != nil {
+		var ok bool
+		if city, ok = p.cities[cep]; !ok {
+			return nil, ErrCEPNotFound
+		}
+	} else if city == "" {
+		city = "São Paulo"
+	}
+	return &models.Address{Cep: cep, Localidade: city, Provider: p.Name()}, nil
+}
+
+func (p *fakeProvider) Current(ctx context.Context, query models.WeatherQuery) (*models.WeatherReading, error) {
+	p.mu.Lock()
+	p.queries = append(p.queries, query)
+	p.mu.Unlock()
+	if err := p.wait(ctx); err != nil {
+		return nil, err
+	}
+	location := p.plain
+	if query.Qualified {
+		location = p.qualified
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return &models.WeatherReading{Provider: p.Name(), TempC: p.tempC, Location: location}, nil
+}
+
+func (p *fakeProvider) setTempC(tempC float64) {
+	p.mu.Lock()
+	p.tempC = tempC
+	p.mu.Unlock()
+}
+
+// wait conta a chamada e aguarda o atraso e o gate; devolve o erro configurado
+func (p *fakeProvider) wait(ctx context.Context) error {
+	p.calls.Add(1)
+	if p.delay > 0 {
+		select {
+		case <-time.After(p.delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if p.gate != nil {
+		select {
+		case <-p.gate:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return p.err
+}
+
+// recordSpans instala um tracer provider que grava os spans finalizados
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
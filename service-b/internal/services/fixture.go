@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"time"
+
+	"service-b/internal/models"
+)
+
+// Distância máxima, em graus, para uma consulta por coordenadas casar com uma entrada
+const fixtureCoordinateTolerance = 0.05
+
+// WeatherFixtures é o conteúdo do arquivo de fixtures do provedor fixture
+type WeatherFixtures struct {
+	Default *WeatherFixture  `json:"default"` // Usada quando nenhuma entrada casa com a consulta
+	Entries []WeatherFixture `json:"entries"`
+}
+
+// WeatherFixture define a resposta para uma cidade ou coordenada
+type WeatherFixture struct {
+	City      string   `json:"city"`
+	Uf        string   `json:"uf"`
+	Lat       *float64 `json:"lat"`
+	Lon       *float64 `json:"lon"`
+	TempC     float64  `json:"temp_c"`
+	Condition string   `json:"condition"`
+	LatencyMs int      `json:"latency_ms"` // Atraso simulado antes da resposta
+	Error     string   `json:"error"`      // Simula uma falha de rede
+	Status    int      `json:"status"`     // Simula um status HTTP de erro do provedor
+}
+
+// FixtureWeatherProvider responde a partir de fixtures, sem acessar a rede.
+// Substitui o valor fixo de 25°C que o TEST_MODE usava.
+type FixtureWeatherProvider struct {
+	fixtures WeatherFixtures
+}
+
+// NewFixtureWeatherProvider carrega as fixtures do arquivo JSON informado. Sem arquivo,
+// todas as cidades respondem 25°C.
+func NewFixtureWeatherProvider(path string) (*FixtureWeatherProvider, error) {
+	fixtures := WeatherFixtures{Default: &WeatherFixture{TempC: 25.0, Condition: "Sunny"}}
+	if path == "" {
+		return &FixtureWeatherProvider{fixtures: fixtures}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fixtures.Default = nil
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		return nil, fmt.Errorf("error loading %s: %w", path, err)
+	}
+	log.Printf("Fixtures de clima carregadas: %d entradas", len(fixtures.Entries))
+	return &FixtureWeatherProvider{fixtures: fixtures}, nil
+}
+
+// Name retorna o nome do provedor
+func (p *FixtureWeatherProvider) Name() string {
+	return "fixture"
+}
+
+// Current responde com a fixture que casa com a consulta, simulando latência e erros
+func (p *FixtureWeatherProvider) Current(ctx context.Context, query models.WeatherQuery) (*models.WeatherReading, error) {
+	fixture := p.match(query)
+	if fixture == nil {
+		return nil, fmt.Errorf("no weather fixture for %s", query.City)
+	}
+
+	if fixture.LatencyMs > 0 {
+		select {
+		case <-time.After(time.Duration(fixture.LatencyMs) * time.Millisecond):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if fixture.Error != "" {
+		return nil, errors.New(fixture.Error)
+	}
+	if fixture.Status != 0 {
+		return nil, fmt.Errorf("Error getting weather data: status %d", fixture.Status)
+	}
+
+	reading := &models.WeatherReading{
+		Provider:  p.Name(),
+		TempC:     fixture.TempC,
+		Condition: fixture.Condition,
+	}
+	// Entradas com UF informam a localidade, para que a conferência de estado funcione
+	if fixture.Uf != "" {
+		reading.Location = &models.ResolvedLocation{
+			Name:    fixture.City,
+			Region:  stateNames[fixture.Uf],
+			Country: "Brazil",
+		}
+	}
+	return reading, nil
+}
+
+// match procura a entrada para a consulta: por coordenadas quando a consulta as tem,
+// depois pelo nome da cidade (e UF, se a entrada a define), e por fim a entrada padrão
+func (p *FixtureWeatherProvider) match(query models.WeatherQuery) *WeatherFixture {
+	if query.Coordinates != nil {
+		for i := range p.fixtures.Entries {
+			entry := &p.fixtures.Entries[i]
+			if entry.Lat != nil && entry.Lon != nil &&
+				math.Abs(*entry.Lat-query.Coordinates.Lat) <= fixtureCoordinateTolerance &&
+				math.Abs(*entry.Lon-query.Coordinates.Lon) <= fixtureCoordinateTolerance {
+				return entry
+			}
+		}
+	}
+
+	for i := range p.fixtures.Entries {
+		entry := &p.fixtures.Entries[i]
+		if entry.City == "" || normalizePlace(entry.City) != normalizePlace(query.City) {
+			continue
+		}
+		if entry.Uf == "" || query.Uf == "" || normalizePlace(entry.Uf) == normalizePlace(query.Uf) {
+			return entry
+		}
+	}
+
+	return p.fixtures.Default
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"service-b/internal/models"
+)
+
+const testFixtures = `{
+	"entries": [
+		{"city": "São Paulo", "uf": "SP", "temp_c": 22.5, "condition": "Cloudy"},
+		{"lat": -29.68, "lon": -53.81, "temp_c": 15.0, "condition": "Rain"},
+		{"city": "Santa Maria", "uf": "PA", "temp_c": 31.0},
+		{"city": "Manaus", "error": "connection reset by peer"},
+		{"city": "Recife", "status": 503},
+		{"city": "Curitiba", "temp_c": 12.0, "latency_ms": 200}
+	]
+}`
+
+func TestFixtureWeatherProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixtures.json")
+	if err := os.WriteFile(path, []byte(testFixtures), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	provider, err := NewFixtureWeatherProvider(path)
+	if err != nil {
+		t.Fatalf("Erro ao carregar fixtures: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		query   models.WeatherQuery
+		tempC   float64
+		wantErr bool
+	}{
+		{"pelo nome sem acento", models.WeatherQuery{City: "Sao Paulo", Uf: "SP"}, 22.5, false},
+		{"por coordenadas", models.WeatherQuery{City: "Santa Maria", Uf: "RS", Coordinates: &models.Coordinates{Lat: -29.6842, Lon: -53.8069}}, 15.0, false},
+		{"pelo nome e UF", models.WeatherQuery{City: "Santa Maria", Uf: "PA"}, 31.0, false},
+		{"erro de rede", models.WeatherQuery{City: "Manaus", Uf: "AM"}, 0, true},
+		{"status de erro", models.WeatherQuery{City: "Recife", Uf: "PE"}, 0, true},
+		{"sem fixture nem padrão", models.WeatherQuery{City: "Natal", Uf: "RN"}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reading, err := provider.Current(context.Background(), tt.query)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Esperado erro, obtido %+v", reading)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if reading.TempC != tt.tempC {
+				t.Errorf("Temperatura incorreta: obtido %v, esperado %v", reading.TempC, tt.tempC)
+			}
+		})
+	}
+
+	t.Run("latência respeita o prazo", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		if _, err := provider.Current(ctx, models.WeatherQuery{City: "Curitiba"}); err == nil {
+			t.Errorf("Esperado erro de prazo")
+		}
+	})
+}
+
+func TestFixtureWeatherProviderDefault(t *testing.T) {
+	provider, err := NewFixtureWeatherProvider("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reading, err := provider.Current(context.Background(), models.WeatherQuery{City: "Qualquer"})
+	if err != nil || reading.TempC != 25.0 {
+		t.Errorf("Leitura padrão incorreta: %+v %v", reading, err)
+	}
+}
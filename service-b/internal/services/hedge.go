@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Amostras de latência mantidas por provedor para o cálculo do p95
+	latencyWindowSize = 100
+	// Amostras mínimas antes de confiar no p95 observado
+	minLatencySamples = 20
+)
+
+// Hedger decide quanto esperar por um provedor antes de disparar a mesma consulta no
+// próximo. O atraso é fixo ou o p95 observado do provedor, com um valor inicial usado
+// enquanto ainda não há amostras suficientes.
+type Hedger struct {
+	delay    time.Duration
+	usesP95  bool
+	mu       sync.Mutex
+	observed map[string][]time.Duration
+}
+
+// NewHedger cria um Hedger a partir da especificação de HEDGE_DELAY: uma duração
+// ("300ms") ou "p95". Retorna nil, desativando o hedging, quando a especificação é vazia.
+// fallback é o atraso usado no modo p95 até haver amostras suficientes.
+func NewHedger(spec string, fallback time.Duration) *Hedger {
+	spec = strings.TrimSpace(strings.ToLower(spec))
+	switch spec {
+	case "", "0", "off":
+		return nil
+	case "p95":
+		return &Hedger{delay: fallback, usesP95: true, observed: make(map[string][]time.Duration)}
+	}
+
+	delay, err := time.ParseDuration(spec)
+	if err != nil || delay <= 0 {
+		log.Printf("Valor inválido para HEDGE_DELAY (%q), hedging desativado", spec)
+		return nil
+	}
+	return &Hedger{delay: delay, observed: make(map[string][]time.Duration)}
+}
+
+// Delay retorna quanto esperar pelo provedor antes de disparar a consulta de hedge
+func (h *Hedger) Delay(provider string) time.Duration {
+	if !h.usesP95 {
+		return h.delay
+	}
+
+	h.mu.Lock()
+	samples := append([]time.Duration(nil), h.observed[provider]...)
+	h.mu.Unlock()
+
+	if len(samples) < minLatencySamples {
+		return h.delay
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return samples[(len(samples)*95)/100]
+}
+
+// Observe registra a latência de uma resposta bem-sucedida do provedor
+func (h *Hedger) Observe(provider string, d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	samples := append(h.observed[provider], d)
+	if len(samples) > latencyWindowSize {
+		samples = samples[len(samples)-latencyWindowSize:]
+	}
+	h.observed[provider] = samples
+}
+
+// attemptFunc executa uma tentativa; hedged indica que ela foi disparada por atraso
+// da anterior e não por falha
+type attemptFunc[T any] func(ctx context.Context, hedged bool) (T, error)
+
+// raceAttempts executa as tentativas em ordem. A próxima é disparada quando a anterior
+// falha ou, se delay retornar um valor positivo, quando ela demora mais que esse prazo.
+// A primeira resposta bem-sucedida ou definitiva (final) vence e as demais são canceladas.
+// Retorna também o índice da tentativa vencedora, ou -1 quando todas falham.
+func raceAttempts[T any](ctx context.Context, attempts []attemptFunc[T], delay func(index int) time.Duration, final func(error) bool) (T, int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		index int
+		value T
+		err   error
+	}
+	results := make(chan result, len(attempts))
+
+	launched, pending := 0, 0
+	var lastLaunch time.Time
+	launch := func(hedged bool) {
+		index := launched
+		launched++
+		pending++
+		lastLaunch = time.Now()
+		go func() {
+			value, err := attempts[index](ctx, hedged)
+			results <- result{index: index, value: value, err: err}
+		}()
+	}
+
+	var zero T
+	var lastErr error
+	launch(false)
+	for pending > 0 {
+		var timeout <-chan time.Time
+		var timer *time.Timer
+		if launched < len(attempts) && delay != nil {
+			if d := delay(launched - 1); d > 0 {
+				timer = time.NewTimer(d - time.Since(lastLaunch))
+				timeout = timer.C
+			}
+		}
+
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil || final(r.err) {
+				stopTimer(timer)
+				return r.value, r.index, r.err
+			}
+			lastErr = r.err
+			// Falha sem outra tentativa em andamento: passa para a próxima
+			if pending == 0 && launched < len(attempts) && ctx.Err() == nil {
+				launch(false)
+			}
+		case <-timeout:
+			launch(true)
+		case <-ctx.Done():
+			stopTimer(timer)
+			return zero, -1, ctx.Err()
+		}
+		stopTimer(timer)
+	}
+	return zero, -1, lastErr
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
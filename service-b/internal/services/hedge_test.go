@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"service-b/internal/models"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestNewHedger(t *testing.T) {
+	if NewHedger("", time.Second) != nil || NewHedger("abc", time.Second) != nil {
+		t.Errorf("Hedging deveria estar desativado")
+	}
+	if h := NewHedger("250ms", time.Second); h == nil || h.Delay("viacep") != 250*time.Millisecond {
+		t.Errorf("Atraso fixo incorreto")
+	}
+
+	h := NewHedger("p95", time.Second)
+	if h.Delay("viacep") != time.Second {
+		t.Errorf("Sem amostras deveria usar o atraso inicial")
+	}
+	for i := 1; i <= 100; i++ {
+		h.Observe("viacep", time.Duration(i)*time.Millisecond)
+	}
+	if d := h.Delay("viacep"); d != 96*time.Millisecond {
+		t.Errorf("p95 incorreto: obtido %s", d)
+	}
+}
+
+func TestCEPProviderChainHedging(t *testing.T) {
+	recorder := recordSpans(t)
+
+	chain := NewCEPProviderChain(
+		&fakeProvider{name: "viacep", delay: time.Second},
+		&fakeProvider{name: "brasilapi", delay: 10 * time.Millisecond},
+	)
+	chain.hedger = NewHedger("20ms", 0)
+
+	start := time.Now()
+	address, err := chain.Lookup(context.Background(), "01001000")
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if address.Provider != "brasilapi" {
+		t.Errorf("Provedor incorreto: obtido %s, esperado brasilapi", address.Provider)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Hedge não foi disparado a tempo: %s", elapsed)
+	}
+
+	// A tentativa perdedora é cancelada e seu span finalizado logo em seguida
+	deadline := time.Now().Add(time.Second)
+	for len(recorder.Ended()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	outcomes := map[string]string{}
+	hedged := map[string]bool{}
+	for _, span := range recorder.Ended() {
+		var provider string
+		for _, attr := range span.Attributes() {
+			switch attr.Key {
+			case "cep.provider":
+				provider = attr.Value.AsString()
+			}
+		}
+		for _, attr := range span.Attributes() {
+			switch attr.Key {
+			case "cep.outcome":
+				outcomes[provider] = attr.Value.AsString()
+			case "hedged":
+				hedged[provider] = attr.Value.AsBool()
+			}
+		}
+	}
+	if outcomes["viacep"] != "cancelled" || outcomes["brasilapi"] != "found" {
+		t.Errorf("Resultados incorretos: %v", outcomes)
+	}
+	if hedged["viacep"] || !hedged["brasilapi"] {
+		t.Errorf("Marcação de hedge incorreta: %v", hedged)
+	}
+}
+
+func TestHedgedReading(t *testing.T) {
+	service := &WeatherService{
+		tracer: otel.Tracer("test"),
+		weatherProviders: []WeatherProvider{
+			&fakeProvider{name: "weatherapi", tempC: 30, delay: time.Second},
+			&fakeProvider{name: "openmeteo", tempC: 22},
+		},
+		hedger: NewHedger("20ms", 0),
+	}
+
+	reading, err := service.hedgedReading(context.Background(), models.WeatherQuery{Coordinates: &models.Coordinates{}})
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if reading.Provider != "openmeteo" || reading.TempC != 22 {
+		t.Errorf("Leitura incorreta: %+v", reading)
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"strings"
+
+	"service-b/internal/models"
+)
+
+// Níveis de confiança de que a leitura de clima é da cidade do CEP
+const (
+	LocationConfidenceHigh       = "high"
+	LocationConfidenceLow        = "low"
+	LocationConfidenceUnverified = "unverified"
+)
+
+// Nome dos estados por UF, como aparecem no campo region dos provedores de clima
+var stateNames = map[string]string{
+	"AC": "Acre",
+	"AL": "Alagoas",
+	"AP": "Amapá",
+	"AM": "Amazonas",
+	"BA": "Bahia",
+	"CE": "Ceará",
+	"DF": "Distrito Federal",
+	"ES": "Espírito Santo",
+	"GO": "Goiás",
+	"MA": "Maranhão",
+	"MT": "Mato Grosso",
+	"MS": "Mato Grosso do Sul",
+	"MG": "Minas Gerais",
+	"PA": "Pará",
+	"PB": "Paraíba",
+	"PR": "Paraná",
+	"PE": "Pernambuco",
+	"PI": "Piauí",
+	"RJ": "Rio de Janeiro",
+	"RN": "Rio Grande do Norte",
+	"RS": "Rio Grande do Sul",
+	"RO": "Rondônia",
+	"RR": "Roraima",
+	"SC": "Santa Catarina",
+	"SP": "São Paulo",
+	"SE": "Sergipe",
+	"TO": "Tocantins",
+}
+
+// qualifiedCityName monta o nome "cidade, UF, Brazil" usado para desambiguar a consulta
+func qualifiedCityName(city, uf string) string {
+	return city + ", " + uf + ", Brazil"
+}
+
+// locationMatches verifica se a localidade resolvida pelo provedor de clima fica no
+// mesmo estado e país do CEP. Retorna false em known quando não há o que comparar.
+func locationMatches(uf string, location *models.ResolvedLocation) (match bool, known bool) {
+	if location == nil || uf == "" || (location.Region == "" && location.Country == "") {
+		return false, false
+	}
+
+	switch normalizePlace(location.Country) {
+	case "", "brazil", "brasil", "br":
+	default:
+		return false, true
+	}
+
+	region := normalizePlace(location.Region)
+	if region == "" {
+		return true, true
+	}
+	return region == normalizePlace(uf) || region == normalizePlace(stateNames[strings.ToUpper(uf)]), true
+}
+
+// normalizePlace remove acentos, espaços nas pontas e diferenças de caixa
+func normalizePlace(name string) string {
+	return strings.ToLower(strings.TrimSpace(removeAccents(name)))
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"service-b/internal/models"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestLocationMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		uf       string
+		location *models.ResolvedLocation
+		match    bool
+		known    bool
+	}{
+		{"mesmo estado", "RS", &models.ResolvedLocation{Region: "Rio Grande do Sul", Country: "Brazil"}, true, true},
+		{"estado com acento", "SP", &models.ResolvedLocation{Region: "Sao Paulo", Country: "Brazil"}, true, true},
+		{"código do país", "PR", &models.ResolvedLocation{Region: "Paraná", Country: "BR"}, true, true},
+		{"outro estado", "RS", &models.ResolvedLocation{Region: "Para", Country: "Brazil"}, false, true},
+		{"outro país", "SP", &models.ResolvedLocation{Region: "Sao Paulo", Country: "Portugal"}, false, true},
+		{"sem localidade", "SP", nil, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, known := locationMatches(tt.uf, tt.location)
+			if match != tt.match || known != tt.known {
+				t.Errorf("Resultado incorreto: obtido (%v, %v), esperado (%v, %v)", match, known, tt.match, tt.known)
+			}
+		})
+	}
+}
+
+func TestGetTemperatureLocationConfidence(t *testing.T) {
+	table, err := NewCoordinateTable("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	santaMariaPA := &models.ResolvedLocation{Name: "Santa Maria", Region: "Para", Country: "Brazil"}
+	santaMariaRS := &models.ResolvedLocation{Name: "Santa Maria", Region: "Rio Grande do Sul", Country: "Brazil"}
+
+	tests := []struct {
+		name       string
+		provider   *fakeProvider
+		confidence string
+		queries    int
+	}{
+		{"localidade correta", &fakeProvider{plain: santaMariaRS}, LocationConfidenceHigh, 1},
+		{"corrigida pela consulta qualificada", &fakeProvider{plain: santaMariaPA, qualified: santaMariaRS}, LocationConfidenceHigh, 2},
+		{"divergência persistente", &fakeProvider{plain: santaMariaPA, qualified: santaMariaPA}, LocationConfidenceLow, 2},
+		{"provedor sem localidade", &fakeProvider{}, LocationConfidenceUnverified, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &WeatherService{
+				tracer:          otel.Tracer("test"),
+				weatherProvider: tt.provider,
+				coordinates:     table,
+			}
+
+			// Sem código IBGE a consulta é feita pelo nome
+			reading, err := service.GetTemperature(context.Background(), &models.Address{Localidade: "Santa Maria", Uf: "RS"})
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if reading.LocationConfidence != tt.confidence {
+				t.Errorf("Confiança incorreta: obtido %s, esperado %s", reading.LocationConfidence, tt.confidence)
+			}
+			if len(tt.provider.queries) != tt.queries {
+				t.Errorf("Número de consultas incorreto: obtido %d, esperado %d", len(tt.provider.queries), tt.queries)
+			}
+		})
+	}
+}
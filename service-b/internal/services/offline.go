@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cep-weather-api/pkg/faults"
+	"service-b/internal/models"
+)
+
+// OfflineCEPProvider responde a partir de uma base de CEPs carregada em memória,
+// sem acessar a rede. Além de CEPs individuais, aceita faixas de CEP, usadas por
+// cidades que têm um único CEP geral.
+type OfflineCEPProvider struct {
+	exact  map[string]*models.Address
+	ranges []cepRange       // Ordenadas pelo início da faixa
+	faults *faults.Injector // Regras de falha do provedor "offline"; pode ser nil
+}
+
+// Faixa de CEPs que resolve para o mesmo endereço
+type cepRange struct {
+	start   string
+	end     string
+	address *models.Address
+}
+
+// NewOfflineCEPProvider carrega a base de CEPs do arquivo CSV informado
+func NewOfflineCEPProvider(path string) (*OfflineCEPProvider, error) {
+	if path == "" {
+		return nil, fmt.Errorf("CEP_DATASET_FILE not set")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	provider, err := LoadCEPDataset(file)
+	if err != nil {
+		return nil, fmt.Errorf("error loading %s: %w", path, err)
+	}
+	log.Printf("Base offline de CEPs carregada: %d CEPs e %d faixas", len(provider.exact), len(provider.ranges))
+	return provider, nil
+}
+
+// LoadCEPDataset lê uma base de CEPs em CSV com cabeçalho. As colunas cep, cidade
+// (ou localidade) e uf são obrigatórias; logradouro, bairro, ibge, latitude e longitude
+// são opcionais. Uma linha com cep_fim preenchido representa a faixa de cep até cep_fim;
+// faixas que se sobrepõem são recusadas, já que o CEP resolveria para mais de um endereço.
+func LoadCEPDataset(r io.Reader) (*OfflineCEPProvider, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.ToLower(name))] = i
+	}
+	if _, ok := columns["localidade"]; ok {
+		if _, ok := columns["cidade"]; !ok {
+			columns["cidade"] = columns["localidade"]
+		}
+	}
+	for _, required := range []string{"cep", "cidade", "uf"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing %s column", required)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	provider := &OfflineCEPProvider{exact: make(map[string]*models.Address)}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		cep := digitsOnly(field(record, "cep"))
+		if len(cep) != 8 {
+			return nil, fmt.Errorf("line %d: invalid CEP %q", line, field(record, "cep"))
+		}
+
+		address := &models.Address{
+			Cep:        cep[:5] + "-" + cep[5:],
+			Logradouro: field(record, "logradouro"),
+			Bairro:     field(record, "bairro"),
+			Localidade: field(record, "cidade"),
+			Uf:         strings.ToUpper(field(record, "uf")),
+			Ibge:       field(record, "ibge"),
+			Provider:   "offline",
+		}
+
+		lat, errLat := strconv.ParseFloat(field(record, "latitude"), 64)
+		lon, errLon := strconv.ParseFloat(field(record, "longitude"), 64)
+		if errLat == nil && errLon == nil {
+			address.Coordinates = &models.Coordinates{Lat: lat, Lon: lon}
+		}
+
+		end := digitsOnly(field(record, "cep_fim"))
+		if end == "" {
+			provider.exact[cep] = address
+			continue
+		}
+		if len(end) != 8 || end < cep {
+			return nil, fmt.Errorf("line %d: invalid CEP range %s-%s", line, cep, end)
+		}
+		provider.ranges = append(provider.ranges, cepRange{start: cep, end: end, address: address})
+	}
+
+	sort.Slice(provider.ranges, func(i, j int) bool { return provider.ranges[i].start < provider.ranges[j].start })
+	for i := 1; i < len(provider.ranges); i++ {
+		previous, current := provider.ranges[i-1], provider.ranges[i]
+		if current.start <= previous.end {
+			return nil, fmt.Errorf("overlapping CEP ranges %s-%s and %s-%s", previous.start, previous.end, current.start, current.end)
+		}
+	}
+	return provider, nil
+}
+
+// Name retorna o nome do provedor
+func (p *OfflineCEPProvider) Name() string {
+	return "offline"
+}
+
+// Lookup busca o CEP na base: primeiro entre os CEPs individuais, depois nas faixas
+func (p *OfflineCEPProvider) Lookup(ctx context.Context, cep string) (*models.Address, error) {
+	// Sem chamada HTTP, as regras de falha são aplicadas aqui
+	status, err := p.faults.Check(ctx, p.Name())
+	if err != nil {
+		return nil, err
+	}
+	if status != 0 && status != http.StatusOK {
+		return nil, cepStatusError(p.Name(), status)
+	}
+
+	if address, ok := p.exact[cep]; ok {
+		result := *address
+		return &result, nil
+	}
+
+	// Sem sobreposição, só a última faixa que começa antes do CEP pode contê-lo
+	i := sort.Search(len(p.ranges), func(i int) bool { return p.ranges[i].start > cep }) - 1
+	if i >= 0 && cep <= p.ranges[i].end {
+		result := *p.ranges[i].address
+		result.Cep = cep[:5] + "-" + cep[5:]
+		return &result, nil
+	}
+
+	return nil, ErrCEPNotFound
+}
+
+// digitsOnly remove a formatação do CEP ("01001-000" vira "01001000")
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"cep-weather-api/pkg/faults"
+)
+
+const testDataset = `cep,logradouro,bairro,cidade,uf,ibge,latitude,longitude,cep_fim
+01001-000,Praça da Sé,Sé,São Paulo,SP,3550308,-23.5503,-46.6340,
+29900-001,,,Linhares,ES,3203205,,,29909-999
+97000-001,,,Santa Maria,RS,4316907,,,97119-999
+`
+
+func TestOfflineCEPProvider(t *testing.T) {
+	provider, err := LoadCEPDataset(strings.NewReader(testDataset))
+	if err != nil {
+		t.Fatalf("Erro ao carregar base: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		cep  string
+		city string
+		err  error
+	}{
+		{"CEP individual", "01001000", "São Paulo", nil},
+		{"início da faixa", "29900001", "Linhares", nil},
+		{"dentro da faixa", "29902555", "Linhares", nil},
+		{"fim da faixa", "97119999", "Santa Maria", nil},
+		{"entre faixas", "50000000", "", ErrCEPNotFound},
+		{"antes de todas as faixas", "00000001", "", ErrCEPNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, err := provider.Lookup(context.Background(), tt.cep)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Erro incorreto: obtido %v, esperado %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if address.Localidade != tt.city || address.Provider != "offline" {
+				t.Errorf("Endereço incorreto: %+v", address)
+			}
+			if address.Cep != tt.cep[:5]+"-"+tt.cep[5:] {
+				t.Errorf("CEP incorreto: obtido %s", address.Cep)
+			}
+		})
+	}
+
+	address, _ := provider.Lookup(context.Background(), "01001000")
+	if address.Coordinates == nil || address.Ibge != "3550308" {
+		t.Errorf("Coordenadas e código IBGE deveriam ser carregados: %+v", address)
+	}
+}
+
+func TestOfflineCEPProviderFaults(t *testing.T) {
+	t.Setenv("SIMULATE_CEP_NOT_FOUND", "true")
+	injector, err := faults.FromEnv(LegacyFaultRules()...)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	provider, err := LoadCEPDataset(strings.NewReader(testDataset))
+	if err != nil {
+		t.Fatalf("Erro ao carregar base: %v", err)
+	}
+	provider.faults = injector
+
+	// A regra de compatibilidade vale também para a base offline
+	if _, err := provider.Lookup(context.Background(), "01001000"); !errors.Is(err, ErrCEPNotFound) {
+		t.Errorf("Erro incorreto: obtido %v, esperado %v", err, ErrCEPNotFound)
+	}
+
+	injector.SetRules([]faults.Rule{{Provider: "offline", Error: "dataset unavailable"}})
+	if _, err := provider.Lookup(context.Background(), "01001000"); err == nil || errors.Is(err, ErrCEPNotFound) {
+		t.Errorf("Erro injetado deveria ser falha do provedor: %v", err)
+	}
+
+	injector.SetRules(nil)
+	if _, err := provider.Lookup(context.Background(), "01001000"); err != nil {
+		t.Errorf("Erro inesperado sem regras: %v", err)
+	}
+}
+
+func TestLoadCEPDatasetErrors(t *testing.T) {
+	tests := map[string]string{
+		"sem coluna uf":            "cep,cidade\n01001000,São Paulo\n",
+		"CEP inválido":             "cep,cidade,uf\n123,São Paulo,SP\n",
+		"faixa invertida":          "cep,cidade,uf,cep_fim\n29909999,Linhares,ES,29900001\n",
+		"faixas sobrepostas":       "cep,cidade,uf,cep_fim\n29900000,Linhares,ES,29999999\n29930000,Sooretama,ES,29939999\n",
+		"faixas com a mesma borda": "cep,cidade,uf,cep_fim\n29900000,Linhares,ES,29929999\n29929999,Sooretama,ES,29939999\n",
+	}
+	for name, content := range tests {
+		if _, err := LoadCEPDataset(strings.NewReader(content)); err == nil {
+			t.Errorf("%s: esperado erro", name)
+		}
+	}
+}
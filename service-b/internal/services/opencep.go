@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"service-b/internal/models"
+)
+
+const openCEPURL = "https://opencep.com/v1/%s"
+
+// OpenCEPProvider consulta a OpenCEP
+type OpenCEPProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewOpenCEPProvider cria o provedor OpenCEP a partir de uma URL com o placeholder do CEP
+func NewOpenCEPProvider(url string, client *http.Client) *OpenCEPProvider {
+	return &OpenCEPProvider{url: url, client: client}
+}
+
+// Name retorna o nome do provedor
+func (p *OpenCEPProvider) Name() string {
+	return "opencep"
+}
+
+// Lookup busca o CEP na OpenCEP
+func (p *OpenCEPProvider) Lookup(ctx context.Context, cep string) (*models.Address, error) {
+	url := fmt.Sprintf(p.url, cep)
+	log.Printf("Consultando CEP: %s", url)
+
+	var openCEPResp models.OpenCEPResponse
+	status, err := getJSON(ctx, p.client, url, &openCEPResp)
+	if err != nil {
+		log.Printf("Erro ao consultar OpenCEP: %v", err)
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		log.Printf("OpenCEP retornou status code: %d", status)
+		return nil, cepStatusError(p.Name(), status)
+	}
+
+	return &models.Address{
+		Cep:         openCEPResp.Cep,
+		Logradouro:  openCEPResp.Logradouro,
+		Complemento: openCEPResp.Complemento,
+		Bairro:      openCEPResp.Bairro,
+		Localidade:  openCEPResp.Localidade,
+		Uf:          openCEPResp.Uf,
+		Ibge:        openCEPResp.Ibge,
+		Provider:    p.Name(),
+	}, nil
+}
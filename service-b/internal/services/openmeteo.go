@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"service-b/internal/models"
+)
+
+const (
+	openMeteoURL          = "https://api.open-meteo.com/v1/forecast?latitude=%f&longitude=%f&current=temperature_2m,weather_code"
+	openMeteoGeocodingURL = "https://geocoding-api.open-meteo.com/v1/search?name=%s&count=10&language=pt&countryCode=BR"
+)
+
+// OpenMeteoProvider consulta a Open-Meteo, que não exige chave de API.
+// A previsão é feita por latitude/longitude; sem coordenadas na consulta, elas
+// são obtidas pela API de geocodificação.
+type OpenMeteoProvider struct {
+	url          string
+	geocodingURL string
+	client       *http.Client
+}
+
+// NewOpenMeteoProvider cria o provedor Open-Meteo a partir das URLs de previsão e de geocodificação
+func NewOpenMeteoProvider(url, geocodingURL string, client *http.Client) *OpenMeteoProvider {
+	return &OpenMeteoProvider{url: url, geocodingURL: geocodingURL, client: client}
+}
+
+// Name retorna o nome do provedor
+func (p *OpenMeteoProvider) Name() string {
+	return "openmeteo"
+}
+
+// Current busca a temperatura atual da cidade
+func (p *OpenMeteoProvider) Current(ctx context.Context, query models.WeatherQuery) (*models.WeatherReading, error) {
+	var location *models.ResolvedLocation
+	coords := query.Coordinates
+	if coords == nil {
+		geocoded, err := p.geocode(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		location = geocoded
+		coords = &models.Coordinates{Lat: geocoded.Lat, Lon: geocoded.Lon}
+	}
+
+	log.Printf("Consultando temperatura na Open-Meteo para %s (%f, %f)", query.City, coords.Lat, coords.Lon)
+
+	var forecastResp models.OpenMeteoForecastResponse
+	status, err := getJSON(ctx, p.client, fmt.Sprintf(p.url, coords.Lat, coords.Lon), &forecastResp)
+	if err != nil {
+		log.Printf("Erro ao consultar Open-Meteo: %v", err)
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		log.Printf("Open-Meteo retornou status code: %d", status)
+		return nil, fmt.Errorf("Error getting weather data: status %d", status)
+	}
+
+	reading := &models.WeatherReading{
+		Provider:       p.Name(),
+		TempC:          forecastResp.Current.Temperature2m,
+		Condition:      weatherCodeText(forecastResp.Current.WeatherCode),
+		Location:       location,
+		UpdateInterval: time.Duration(forecastResp.Current.Interval) * time.Second,
+	}
+	// O horário da observação vem em GMT, sem fuso
+	if observed, err := time.Parse("2006-01-02T15:04", forecastResp.Current.Time); err == nil {
+		reading.ObservedAt = observed
+	}
+	return reading, nil
+}
+
+// geocode resolve o nome da cidade em coordenadas, usado quando a consulta não as traz.
+// Numa consulta qualificada, escolhe entre os homônimos o que fica na UF do CEP.
+func (p *OpenMeteoProvider) geocode(ctx context.Context, query models.WeatherQuery) (*models.ResolvedLocation, error) {
+	var geoResp models.OpenMeteoGeocodingResponse
+	status, err := getJSON(ctx, p.client, fmt.Sprintf(p.geocodingURL, url.QueryEscape(query.City)), &geoResp)
+	if err != nil {
+		log.Printf("Erro ao consultar geocodificação da Open-Meteo: %v", err)
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("Error geocoding city: status %d", status)
+	}
+
+	if len(geoResp.Results) == 0 {
+		return nil, fmt.Errorf("location not found: %s", query.City)
+	}
+
+	var locations []*models.ResolvedLocation
+	for _, result := range geoResp.Results {
+		locations = append(locations, &models.ResolvedLocation{
+			Name:    result.Name,
+			Region:  result.Admin1,
+			Country: result.CountryCode,
+			Lat:     result.Latitude,
+			Lon:     result.Longitude,
+		})
+	}
+
+	if query.Qualified {
+		for _, location := range locations {
+			if match, _ := locationMatches(query.Uf, location); match {
+				return location, nil
+			}
+		}
+	}
+	return locations[0], nil
+}
+
+// weatherCodeText traduz os códigos WMO usados pela Open-Meteo
+func weatherCodeText(code int) string {
+	switch {
+	case code == 0:
+		return "Clear sky"
+	case code == 1:
+		return "Mainly clear"
+	case code == 2:
+		return "Partly cloudy"
+	case code == 3:
+		return "Overcast"
+	case code == 45 || code == 48:
+		return "Fog"
+	case code >= 51 && code <= 57:
+		return "Drizzle"
+	case code >= 61 && code <= 67:
+		return "Rain"
+	case code >= 71 && code <= 77:
+		return "Snow"
+	case code >= 80 && code <= 82:
+		return "Rain showers"
+	case code == 85 || code == 86:
+		return "Snow showers"
+	case code >= 95:
+		return "Thunderstorm"
+	default:
+		return ""
+	}
+}
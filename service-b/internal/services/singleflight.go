@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// flightGroup agrupa chamadas simultâneas com a mesma chave: a primeira (líder) faz a
+// consulta aos provedores e as demais (seguidoras) aguardam o mesmo resultado. A
+// consulta roda num span próprio, filho do span do líder, ao qual os spans das
+// seguidoras são ligados por span links.
+type flightGroup[T any] struct {
+	tracer trace.Tracer
+	name   string
+
+	mu      sync.Mutex
+	flights map[string]*flight[T]
+}
+
+// Consulta em andamento
+type flight[T any] struct {
+	done      chan struct{}
+	span      trace.SpanContext
+	followers int
+	val       T
+	err       error
+}
+
+func newFlightGroup[T any](tracer trace.Tracer, name string) *flightGroup[T] {
+	return &flightGroup[T]{tracer: tracer, name: name, flights: make(map[string]*flight[T])}
+}
+
+// Do executa fn uma única vez para as chamadas simultâneas com a mesma chave. A consulta
+// não é cancelada quando o líder desiste, para não derrubar as seguidoras, mas mantém o
+// prazo do líder (o orçamento da requisição), que vale também para as seguidoras; cada
+// chamador deixa de esperar quando o próprio contexto termina. Sem grupo, fn é
+// executada direto.
+func (g *flightGroup[T]) Do(ctx context.Context, key string, fn func(ctx context.Context, span trace.Span) (T, error)) (T, error) {
+	if g == nil {
+		return fn(ctx, trace.SpanFromContext(ctx))
+	}
+
+	g.mu.Lock()
+	f, ok := g.flights[key]
+	if ok {
+		f.followers++
+		caller := trace.SpanFromContext(ctx)
+		caller.AddLink(trace.Link{SpanContext: f.span})
+		caller.SetAttributes(attribute.Bool("coalesced", true))
+	} else {
+		f = &flight[T]{done: make(chan struct{})}
+		g.flights[key] = f
+
+		callCtx, cancel := context.WithoutCancel(ctx), context.CancelFunc(func() {})
+		if deadline, ok := ctx.Deadline(); ok {
+			callCtx, cancel = context.WithDeadline(callCtx, deadline)
+		}
+		callCtx, span := g.tracer.Start(callCtx, g.name)
+		span.SetAttributes(attribute.String("coalesce.key", key))
+		f.span = span.SpanContext()
+
+		go func() {
+			defer cancel()
+			f.val, f.err = fn(callCtx, span)
+			if f.err != nil {
+				span.RecordError(f.err)
+			}
+
+			g.mu.Lock()
+			delete(g.flights, key)
+			span.SetAttributes(attribute.Int("coalesce.followers", f.followers))
+			g.mu.Unlock()
+
+			span.End()
+			close(f.done)
+		}()
+	}
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.val, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"service-b/internal/models"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestGetCityByCEPCoalescing(t *testing.T) {
+	recorder := recordSpans(t)
+
+	const callers = 5
+	tracer := otel.GetTracerProvider().Tracer("weather-service")
+	provider := &fakeProvider{gate: make(chan struct{})}
+	service := &WeatherService{
+		tracer:      tracer,
+		cepProvider: provider,
+		cepFlights:  newFlightGroup[*models.Address](tracer, "cep-lookup"),
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.GetCityByCEP(context.Background(), "01001000"); err != nil {
+				t.Errorf("Erro inesperado: %v", err)
+			}
+		}()
+	}
+
+	// Libera o provedor só depois que todas as seguidoras aguardam a consulta do líder
+	deadline := time.Now().Add(time.Second)
+	for {
+		service.cepFlights.mu.Lock()
+		f := service.cepFlights.flights["01001000"]
+		joined := f != nil && f.followers == callers-1
+		service.cepFlights.mu.Unlock()
+		if joined {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Seguidoras não se juntaram à consulta")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(provider.gate)
+	wg.Wait()
+
+	if calls := provider.calls.Load(); calls != 1 {
+		t.Errorf("Provedor deveria ser consultado uma vez, foi %d", calls)
+	}
+
+	var lookupID string
+	linked := 0
+	for _, span := range recorder.Ended() {
+		if span.Name() == "cep-lookup" {
+			lookupID = span.SpanContext().SpanID().String()
+		}
+	}
+	for _, span := range recorder.Ended() {
+		if span.Name() != "get-city-by-cep" {
+			continue
+		}
+		for _, link := range span.Links() {
+			if link.SpanContext.SpanID().String() == lookupID {
+				linked++
+			}
+		}
+	}
+	if lookupID == "" || linked != callers-1 {
+		t.Errorf("Seguidoras deveriam estar ligadas ao span da consulta: %d de %d", linked, callers-1)
+	}
+}
+
+func TestCoalescedLookupKeepsDeadline(t *testing.T) {
+	recordSpans(t)
+
+	tracer := otel.GetTracerProvider().Tracer("weather-service")
+	service := &WeatherService{
+		tracer:      tracer,
+		cepProvider: &fakeProvider{delay: 5 * time.Second},
+		cepFlights:  newFlightGroup[*models.Address](tracer, "cep-lookup"),
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	leader := make(chan error, 1)
+	go func() {
+		_, err := service.GetCityByCEP(ctx, "01001000")
+		leader <- err
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		service.cepFlights.mu.Lock()
+		started := service.cepFlights.flights["01001000"] != nil
+		service.cepFlights.mu.Unlock()
+		if started {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Consulta do líder não começou")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// A seguidora, sem prazo próprio, recebe o resultado da consulta cortada no prazo do líder
+	_, err := service.GetCityByCEP(context.Background(), "01001000")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Seguidora deveria receber o prazo esgotado, obtido %v", err)
+	}
+	if err := <-leader; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Líder deveria receber o prazo esgotado, obtido %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Consulta deveria terminar no prazo da requisição, levou %s", elapsed)
+	}
+}
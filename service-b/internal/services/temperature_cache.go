@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"service-b/internal/cache"
+	"service-b/internal/models"
+)
+
+// Situação da leitura em relação ao cache de temperatura, enviada no cabeçalho X-Cache
+const (
+	CacheHit   = "HIT"
+	CacheStale = "STALE"
+	CacheMiss  = "MISS"
+)
+
+const (
+	// Validade mínima de uma leitura, usada quando o provedor já deveria ter atualizado os dados
+	minTemperatureTTL = 30 * time.Second
+
+	// Prazo da atualização em segundo plano de uma leitura vencida
+	temperatureRefreshTimeout = 10 * time.Second
+)
+
+// Leitura em cache e o momento até o qual ela é considerada atual
+type temperatureEntry struct {
+	City       string                `json:"city"` // Cidade do CEP, usada na administração do cache
+	Reading    models.WeatherReading `json:"reading"`
+	FreshUntil time.Time             `json:"fresh_until"`
+	StoredAt   time.Time             `json:"stored_at"`
+}
+
+// temperatureCache guarda as leituras por localidade. Uma leitura vale até a próxima
+// atualização prevista do provedor (last_updated + intervalo, limitada a maxTTL) e,
+// depois disso, ainda pode ser servida por mais stale enquanto uma única atualização
+// roda em segundo plano. Passada também essa janela, a leitura continua guardada até
+// completar lastKnown de idade, para as respostas degradadas quando os provedores falham.
+type temperatureCache struct {
+	entries   *cache.Store[temperatureEntry]
+	maxTTL    time.Duration
+	stale     time.Duration
+	lastKnown time.Duration
+	now       func() time.Time
+
+	mu         sync.Mutex
+	refreshing map[string]bool
+}
+
+func newTemperatureCache(entries *cache.Store[temperatureEntry], maxTTL, stale, lastKnown time.Duration) *temperatureCache {
+	return &temperatureCache{
+		entries:    entries,
+		maxTTL:     maxTTL,
+		stale:      stale,
+		lastKnown:  lastKnown,
+		now:        time.Now,
+		refreshing: make(map[string]bool),
+	}
+}
+
+// get retorna uma cópia da leitura da chave e se ela é atual (CacheHit) ou vencida
+// (CacheStale); sem leitura utilizável, retorna CacheMiss. Uma leitura guardada só
+// para as respostas degradadas conta como falha do cache, não como acerto.
+func (c *temperatureCache) get(ctx context.Context, key string) (*models.WeatherReading, string) {
+	now := c.now()
+	entry, ok := c.entries.GetIf(ctx, key, func(entry temperatureEntry) bool {
+		return now.Before(entry.FreshUntil.Add(c.stale))
+	})
+	if !ok {
+		return nil, CacheMiss
+	}
+
+	reading := entry.Reading
+	if now.Before(entry.FreshUntil) {
+		return &reading, CacheHit
+	}
+	return &reading, CacheStale
+}
+
+// lastKnownReading retorna uma cópia da última leitura da chave, mesmo fora da janela de
+// vencida, e o momento da observação, desde que ela não tenha mais que lastKnown de idade
+func (c *temperatureCache) lastKnownReading(ctx context.Context, key string) (*models.WeatherReading, time.Time, bool) {
+	if c.lastKnown <= 0 {
+		return nil, time.Time{}, false
+	}
+	// A consulta de get já foi contada; esta leitura não muda as estatísticas do cache
+	entry, ok := c.entries.Peek(ctx, key)
+	if !ok {
+		return nil, time.Time{}, false
+	}
+
+	// Sem o horário informado pelo provedor, vale o momento em que a leitura foi gravada
+	observedAt := entry.Reading.ObservedAt
+	if observedAt.IsZero() {
+		observedAt = entry.StoredAt
+	}
+	if observedAt.IsZero() || c.now().Sub(observedAt) > c.lastKnown {
+		return nil, time.Time{}, false
+	}
+	reading := entry.Reading
+	return &reading, observedAt, true
+}
+
+// put grava a leitura com a validade calculada a partir da observação do provedor
+func (c *temperatureCache) put(ctx context.Context, key, city string, reading *models.WeatherReading) {
+	ttl := c.ttl(reading)
+	now := c.now()
+	entry := temperatureEntry{City: city, Reading: *reading, FreshUntil: now.Add(ttl), StoredAt: now}
+	c.entries.Set(ctx, key, entry, max(ttl+c.stale, c.lastKnown))
+}
+
+// ttl alinha a validade à próxima atualização prevista do provedor
+func (c *temperatureCache) ttl(reading *models.WeatherReading) time.Duration {
+	if reading.ObservedAt.IsZero() || reading.UpdateInterval <= 0 {
+		return c.maxTTL
+	}
+
+	ttl := reading.ObservedAt.Add(reading.UpdateInterval).Sub(c.now())
+	if ttl < minTemperatureTTL {
+		ttl = minTemperatureTTL
+	}
+	if ttl > c.maxTTL {
+		ttl = c.maxTTL
+	}
+	return ttl
+}
+
+// startRefresh marca a chave como em atualização, retornando false se outra
+// atualização já estiver em andamento
+func (c *temperatureCache) startRefresh(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.refreshing[key] {
+		return false
+	}
+	c.refreshing[key] = true
+	return true
+}
+
+// finishRefresh libera a chave para uma nova atualização
+func (c *temperatureCache) finishRefresh(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.refreshing, key)
+}
+
+// temperatureKey identifica a localidade da consulta: as coordenadas arredondadas
+// (cerca de 1 km) ou o nome da cidade com a UF
+func temperatureKey(query models.WeatherQuery) string {
+	if query.Coordinates != nil {
+		return fmt.Sprintf("coord:%.2f,%.2f", query.Coordinates.Lat, query.Coordinates.Lon)
+	}
+	return fmt.Sprintf("name:%s/%s", normalizePlace(query.City), normalizePlace(query.Uf))
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"service-b/internal/cache"
+	"service-b/internal/models"
+
+	"go.opentelemetry.io/otel"
+)
+
+func newMemoryTemperatureStore() *cache.Store[temperatureEntry] {
+	return cache.NewStore[temperatureEntry]("weather", cache.NewMemory(10), "", cache.JSONCodec{})
+}
+
+func TestTemperatureCacheTTL(t *testing.T) {
+	now := time.Now()
+	c := newTemperatureCache(newMemoryTemperatureStore(), 5*time.Minute, time.Minute, 0)
+	c.now = func() time.Time { return now }
+
+	tests := []struct {
+		name    string
+		reading models.WeatherReading
+		want    time.Duration
+	}{
+		{"sem horário de observação", models.WeatherReading{}, 5 * time.Minute},
+		{"próxima atualização em 2 minutos", models.WeatherReading{ObservedAt: now.Add(-13 * time.Minute), UpdateInterval: 15 * time.Minute}, 2 * time.Minute},
+		{"atualização atrasada", models.WeatherReading{ObservedAt: now.Add(-time.Hour), UpdateInterval: 15 * time.Minute}, minTemperatureTTL},
+		{"limitado ao TTL máximo", models.WeatherReading{ObservedAt: now, UpdateInterval: time.Hour}, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.ttl(&tt.reading); got != tt.want {
+				t.Errorf("TTL incorreto: obtido %s, esperado %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTemperatureCache(t *testing.T) {
+	recordSpans(t)
+
+	provider := &fakeProvider{name: "weatherapi", tempC: 20}
+	now := time.Now()
+	service := &WeatherService{
+		tracer:           otel.GetTracerProvider().Tracer("weather-service"),
+		weatherProvider:  provider,
+		coordinates:      &CoordinateTable{},
+		temperatureCache: newTemperatureCache(newMemoryTemperatureStore(), time.Minute, 10*time.Minute, 0),
+	}
+	service.temperatureCache.now = func() time.Time { return now }
+	address := &models.Address{Localidade: "São Paulo", Uf: "SP", Coordinates: &models.Coordinates{Lat: -23.55, Lon: -46.63}}
+
+	get := func(wantStatus string, wantTemp float64) {
+		t.Helper()
+		reading, err := service.GetTemperature(context.Background(), address)
+		if err != nil {
+			t.Fatalf("Erro inesperado: %v", err)
+		}
+		if reading.CacheStatus != wantStatus || reading.TempC != wantTemp {
+			t.Errorf("Leitura incorreta: obtido %s %.0f, esperado %s %.0f", reading.CacheStatus, reading.TempC, wantStatus, wantTemp)
+		}
+	}
+
+	get(CacheMiss, 20)
+	get(CacheHit, 20)
+
+	// Após a validade, o valor antigo é servido e uma única atualização é disparada
+	provider.setTempC(25)
+	provider.gate = make(chan struct{})
+	now = now.Add(2 * time.Minute)
+	get(CacheStale, 20)
+	get(CacheStale, 20)
+	close(provider.gate)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if reading, status := service.temperatureCache.get(context.Background(), temperatureKey(service.weatherQuery(address))); status == CacheHit && reading.TempC == 25 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Atualização em segundo plano não concluída")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if calls := provider.calls.Load(); calls != 2 {
+		t.Errorf("Provedor deveria ser consultado 2 vezes, foi %d", calls)
+	}
+	get(CacheHit, 25)
+}
+
+func TestLastKnownTemperature(t *testing.T) {
+	recorder := recordSpans(t)
+
+	provider := &fakeProvider{name: "weatherapi", tempC: 20}
+	now := time.Now()
+	service := &WeatherService{
+		tracer:           otel.GetTracerProvider().Tracer("weather-service"),
+		weatherProvider:  provider,
+		coordinates:      &CoordinateTable{},
+		temperatureCache: newTemperatureCache(newMemoryTemperatureStore(), time.Minute, time.Minute, time.Hour),
+	}
+	service.temperatureCache.now = func() time.Time { return now }
+	address := &models.Address{Localidade: "Curitiba", Uf: "PR", Coordinates: &models.Coordinates{Lat: -25.43, Lon: -49.27}}
+	key := temperatureKey(service.weatherQuery(address))
+
+	if _, _, ok := service.LastKnownTemperature(context.Background(), address); ok {
+		t.Fatal("Sem leitura em cache não deveria haver leitura conhecida")
+	}
+	if _, err := service.GetTemperature(context.Background(), address); err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	storedAt := now
+
+	// Fora da janela de vencida a leitura não é mais servida, mas continua conhecida
+	now = now.Add(30 * time.Minute)
+	if _, status := service.temperatureCache.get(context.Background(), key); status != CacheMiss {
+		t.Errorf("Leitura fora da janela de vencida deveria ser MISS, obtido %s", status)
+	}
+	reading, observedAt, ok := service.LastKnownTemperature(context.Background(), address)
+	if !ok || reading.TempC != 20 || !observedAt.Equal(storedAt) {
+		t.Fatalf("Última leitura incorreta: %v, %v, %v", reading, observedAt, ok)
+	}
+
+	// A leitura guardada só para as respostas degradadas conta como falha do cache, e a
+	// busca da última leitura conhecida não entra nas estatísticas
+	if stats := service.temperatureCache.entries.Stats(); stats.Hits != 0 || stats.Misses != 2 {
+		t.Errorf("Estatísticas incorretas: %d acertos, %d falhas (esperados 0 e 2)", stats.Hits, stats.Misses)
+	}
+	for _, span := range recorder.Ended() {
+		if span.Name() != "cache-get" {
+			continue
+		}
+		for _, attr := range span.Attributes() {
+			if attr.Key == "cache.hit" && attr.Value.AsBool() {
+				t.Errorf("Span cache-get não deveria indicar acerto")
+			}
+		}
+	}
+
+	// O horário de observação do provedor prevalece sobre o da gravação
+	observed := now.Add(-10 * time.Minute)
+	service.temperatureCache.put(context.Background(), key, "Curitiba", &models.WeatherReading{TempC: 18, ObservedAt: observed})
+	if _, observedAt, _ := service.LastKnownTemperature(context.Background(), address); !observedAt.Equal(observed) {
+		t.Errorf("Horário de observação incorreto: obtido %v, esperado %v", observedAt, observed)
+	}
+
+	// Leitura mais velha que o limite não é usada
+	now = now.Add(time.Hour)
+	if _, _, ok := service.LastKnownTemperature(context.Background(), address); ok {
+		t.Error("Leitura com mais de 1h não deveria ser usada")
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"cep-weather-api/pkg/breaker"
+	"cep-weather-api/pkg/faults"
+	"cep-weather-api/pkg/retry"
+	"service-b/internal/ratelimit"
+)
+
+// ClientFactory cria o cliente HTTP usado nas chamadas a um provedor. Cada provedor
+// recebe o seu, para que as camadas do transport (como a injeção de falhas) saibam
+// a quem a chamada se destina.
+type ClientFactory func(provider string) *http.Client
+
+// client retorna o cliente do provedor; sem fábrica, usa um cliente padrão
+func (f ClientFactory) client(provider string) *http.Client {
+	if f == nil {
+		return &http.Client{}
+	}
+	return f(provider)
+}
+
+// NewClientFactory cria a fábrica de clientes dos provedores. O transport é envolvido
+// pelo injetor de falhas, quando ele é informado, e, nos provedores de clima, pelo
+// limitador de cota em limiters, de modo que cada tentativa gaste um token e conte na
+// cota. Acima deles ficam as novas tentativas de upstreamRetryPolicy, que também
+// repetem as falhas injetadas mas não as recusas do limitador, e o circuito do provedor
+// em breakers, que conta a chamada com as novas tentativas como uma só e não conta as
+// recusas do limitador. breakers e limiters podem ser nil. O timeout de upstreamTimeout
+// vale para a chamada inteira.
+func NewClientFactory(injector *faults.Injector, breakers *breaker.Registry, limiters *ratelimit.Registry) ClientFactory {
+	return func(provider string) *http.Client {
+		transport := http.DefaultTransport
+		if injector != nil {
+			transport = injector.Transport(provider, transport)
+		}
+		policy := upstreamRetryPolicy(provider)
+		if limiters != nil && !cepProviderNames[provider] {
+			limiter := limiters.Get(provider)
+			transport = limiter.Transport(transport)
+			if limiter.Enabled() {
+				// Com cota configurada, um 429 indica que ela acabou no provedor e repetir
+				// só gastaria mais da cota
+				policy.RetryOn = slices.DeleteFunc(slices.Clone(policy.RetryOn), func(status int) bool {
+					return status == http.StatusTooManyRequests
+				})
+			}
+		}
+		policy.IsPermanent = isRateLimited
+		transport = policy.Transport(provider, transport)
+		if breakers != nil {
+			transport = breakers.Get(provider).Transport(transport)
+		}
+		return &http.Client{Transport: transport, Timeout: upstreamTimeout(provider)}
+	}
+}
+
+// isRateLimited informa se o erro é uma recusa do limitador de cota
+func isRateLimited(err error) bool {
+	return errors.Is(err, ratelimit.ErrLimited)
+}
+
+// Configuração padrão dos limitadores dos provedores de clima: sem limite e, quando
+// configurado, com espera na fila de até 2s
+var defaultRateLimitConfig = ratelimit.Config{Wait: true, MaxWait: 2 * time.Second}
+
+// NewRateLimitRegistry cria o registro dos limitadores dos provedores de clima,
+// configurados por RATE_LIMIT_MODE e RATE_LIMIT_MAX_WAIT e por <PROVEDOR>_RATE_PER_MINUTE,
+// <PROVEDOR>_RATE_BURST e <PROVEDOR>_MONTHLY_QUOTA (por exemplo, WEATHERAPI_MONTHLY_QUOTA).
+// As cotas mensais são contadas em counter, com as chaves prefixadas por prefix.
+func NewRateLimitRegistry(counter ratelimit.Counter, prefix string) *ratelimit.Registry {
+	return ratelimit.NewRegistry(func(provider string) ratelimit.Config {
+		config := rateLimitConfig(provider)
+		config.Counter, config.KeyPrefix = counter, prefix
+		return config
+	})
+}
+
+func rateLimitConfig(provider string) ratelimit.Config {
+	return ratelimit.FromEnv(strings.ToUpper(provider)+"_", ratelimit.FromEnv("", defaultRateLimitConfig))
+}
+
+// checkMonthlyQuotas recusa cotas mensais sem um contador compartilhado: cada réplica
+// contaria a sua, e o Cloud Run cria e encerra réplicas conforme a carga
+func checkMonthlyQuotas(providers []WeatherProvider, shared bool) error {
+	if shared {
+		return nil
+	}
+	for _, provider := range providers {
+		if rateLimitConfig(provider.Name()).MonthlyQuota > 0 {
+			return fmt.Errorf("%s_MONTHLY_QUOTA requires CACHE_BACKEND=redis to share the count between replicas", strings.ToUpper(provider.Name()))
+		}
+	}
+	return nil
+}
+
+// Configuração padrão dos circuitos dos provedores
+var defaultBreakerConfig = breaker.Config{
+	FailureThreshold: 5,
+	OpenTimeout:      30 * time.Second,
+	HalfOpenProbes:   1,
+	IsFailure: func(resp *http.Response, err error) bool {
+		if err != nil {
+			return !isRateLimited(err)
+		}
+		return resp.StatusCode >= 500
+	},
+}
+
+// NewBreakerRegistry cria o registro dos circuitos dos provedores, configurados por
+// BREAKER_* e, acima delas, <PROVEDOR>_BREAKER_* (por exemplo, WEATHERAPI_BREAKER_OPEN_TIMEOUT)
+func NewBreakerRegistry() *breaker.Registry {
+	return breaker.NewRegistry(func(provider string) breaker.Config {
+		return breaker.FromEnv(strings.ToUpper(provider)+"_", breaker.FromEnv("", defaultBreakerConfig))
+	})
+}
+
+// Política padrão de novas tentativas das consultas aos provedores
+var defaultRetryPolicy = retry.Policy{
+	MaxAttempts: 3,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    time.Second,
+	RetryOn:     []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+}
+
+// upstreamRetryPolicy retorna a política de novas tentativas do provedor: RETRY_* e,
+// acima delas, <PROVEDOR>_RETRY_* (por exemplo, VIACEP_RETRY_MAX_ATTEMPTS)
+func upstreamRetryPolicy(provider string) retry.Policy {
+	return retry.FromEnv(strings.ToUpper(provider)+"_", retry.FromEnv("", defaultRetryPolicy))
+}
+
+// Provedores de CEP; os demais são provedores de clima
+var cepProviderNames = map[string]bool{"viacep": true, "brasilapi": true, "opencep": true}
+
+// upstreamTimeout retorna o timeout de cada chamada ao provedor: <PROVEDOR>_TIMEOUT
+// (por exemplo, VIACEP_TIMEOUT) ou, sem ele, CEP_PROVIDER_TIMEOUT ou
+// WEATHER_PROVIDER_TIMEOUT conforme o tipo do provedor. O prazo da requisição,
+// quando menor, continua valendo.
+func upstreamTimeout(provider string) time.Duration {
+	fallback := envDuration("WEATHER_PROVIDER_TIMEOUT", 5*time.Second)
+	if cepProviderNames[provider] {
+		fallback = envDuration("CEP_PROVIDER_TIMEOUT", 3*time.Second)
+	}
+	return envDuration(strings.ToUpper(provider)+"_TIMEOUT", fallback)
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"cep-weather-api/pkg/breaker"
+	"service-b/internal/ratelimit"
+)
+
+func TestUpstreamTimeout(t *testing.T) {
+	t.Setenv("CEP_PROVIDER_TIMEOUT", "2s")
+	t.Setenv("WEATHER_PROVIDER_TIMEOUT", "4s")
+	t.Setenv("OPENCEP_TIMEOUT", "500ms")
+
+	tests := []struct {
+		provider string
+		want     time.Duration
+	}{
+		{"viacep", 2 * time.Second},
+		{"opencep", 500 * time.Millisecond},
+		{"weatherapi", 4 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			if got := NewClientFactory(nil, nil, nil)(tt.provider).Timeout; got != tt.want {
+				t.Errorf("Timeout incorreto: obtido %s, esperado %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientFactoryRateLimit(t *testing.T) {
+	t.Setenv("RETRY_BASE_DELAY", "1ms")
+	t.Setenv("RETRY_MAX_DELAY", "1ms")
+
+	tests := []struct {
+		name      string
+		quota     int   // Cota mensal do provedor
+		statuses  []int // Respostas do provedor, em ordem; 200 depois delas
+		wantCalls int64
+		wantUsed  int // Consumo da cota ao fim da chamada
+		wantErr   bool
+	}{
+		{"cada tentativa gasta a cota", 10, []int{503, 503}, 3, 3, false},
+		{"429 não é repetido com cota", 10, []int{429}, 1, 1, false},
+		{"recusa do limitador não é repetida nem abre o circuito", 1, []int{503}, 1, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if n := int(calls.Add(1)); n <= len(tt.statuses) {
+					w.WriteHeader(tt.statuses[n-1])
+				}
+			}))
+			defer server.Close()
+
+			limiters := ratelimit.NewRegistry(func(string) ratelimit.Config {
+				return ratelimit.Config{MonthlyQuota: tt.quota}
+			})
+			breakers := breaker.NewRegistry(func(string) breaker.Config {
+				config := defaultBreakerConfig
+				config.FailureThreshold = 1
+				return config
+			})
+			client := NewClientFactory(nil, breakers, limiters)("weatherapi")
+
+			resp, err := client.Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if tt.wantErr {
+				// Sem cota para a nova tentativa, a chamada termina na recusa do limitador,
+				// que não conta como falha do provedor
+				if !errors.Is(err, ratelimit.ErrLimited) {
+					t.Fatalf("Esperada recusa do limitador, obtido %v", err)
+				}
+				if state := breakers.Get("weatherapi").State(); state != breaker.Closed {
+					t.Errorf("Recusa do limitador não deveria abrir o circuito: %s", state)
+				}
+			} else if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("Provedor deveria ser chamado %d vezes, foi %d", tt.wantCalls, got)
+			}
+			if used := limiters.Get("weatherapi").Usage().UsedThisMonth; used != tt.wantUsed {
+				t.Errorf("Consumo da cota incorreto: obtido %d, esperado %d", used, tt.wantUsed)
+			}
+		})
+	}
+}
+
+func TestCheckMonthlyQuotas(t *testing.T) {
+	t.Setenv("WEATHERAPI_MONTHLY_QUOTA", "1000000")
+	providers := []WeatherProvider{&fakeProvider{name: "openmeteo"}, &fakeProvider{name: "weatherapi"}}
+
+	if err := checkMonthlyQuotas(providers, false); err == nil {
+		t.Errorf("Cota mensal sem contador compartilhado deveria ser recusada")
+	}
+	if err := checkMonthlyQuotas(providers, true); err != nil {
+		t.Errorf("Erro inesperado com contador compartilhado: %v", err)
+	}
+	if err := checkMonthlyQuotas(providers[:1], false); err != nil {
+		t.Errorf("Provedor sem cota não depende do contador: %v", err)
+	}
+}
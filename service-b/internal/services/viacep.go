@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"service-b/internal/models"
+)
+
+const viaCEPURL = "https://viacep.com.br/ws/%s/json/"
+
+// ViaCEPProvider consulta o ViaCEP
+type ViaCEPProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewViaCEPProvider cria o provedor ViaCEP a partir de uma URL com o placeholder do CEP
+func NewViaCEPProvider(url string, client *http.Client) *ViaCEPProvider {
+	return &ViaCEPProvider{url: url, client: client}
+}
+
+// Name retorna o nome do provedor
+func (p *ViaCEPProvider) Name() string {
+	return "viacep"
+}
+
+// Lookup busca o CEP no ViaCEP
+func (p *ViaCEPProvider) Lookup(ctx context.Context, cep string) (*models.Address, error) {
+	url := fmt.Sprintf(p.url, cep)
+	log.Printf("Consultando CEP: %s", url)
+
+	var viaCEPResp models.ViaCEPResponse
+	status, err := getJSON(ctx, p.client, url, &viaCEPResp)
+	if err != nil {
+		log.Printf("Erro ao consultar ViaCEP: %v", err)
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		log.Printf("ViaCEP retornou status code: %d", status)
+		return nil, cepStatusError(p.Name(), status)
+	}
+
+	// Checar se a resposta contém erro
+	if viaCEPResp.Erro {
+		return nil, ErrCEPNotFound
+	}
+
+	return &models.Address{
+		Cep:         viaCEPResp.Cep,
+		Logradouro:  viaCEPResp.Logradouro,
+		Complemento: viaCEPResp.Complemento,
+		Bairro:      viaCEPResp.Bairro,
+		Localidade:  viaCEPResp.Localidade,
+		Uf:          viaCEPResp.Uf,
+		Ibge:        viaCEPResp.Ibge,
+		Ddd:         viaCEPResp.Ddd,
+		Provider:    p.Name(),
+	}, nil
+}
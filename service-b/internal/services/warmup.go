@@ -0,0 +1,152 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"cep-weather-api/pkg/faults"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// WarmUpResult resume o aquecimento dos caches
+type WarmUpResult struct {
+	Total    int
+	Resolved int
+	Failed   int
+	TimedOut bool
+	Duration time.Duration
+}
+
+// LoadWarmUpCEPs lê o arquivo de CEPs do aquecimento: um CEP por linha, com ou sem
+// hífen. Linhas vazias e iniciadas por # são ignoradas.
+func LoadWarmUpCEPs(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var ceps []string
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		cep := digitsOnly(text)
+		if len(cep) != 8 {
+			return nil, fmt.Errorf("line %d: invalid CEP %q", line, text)
+		}
+		ceps = append(ceps, cep)
+	}
+	return ceps, scanner.Err()
+}
+
+// WarmUpFromEnv aquece os caches com os CEPs de WARMUP_FILE, com até WARMUP_CONCURRENCY
+// consultas simultâneas e dentro do prazo WARMUP_TIMEOUT. Sem arquivo, não faz nada.
+func (s *WeatherService) WarmUpFromEnv(ctx context.Context) error {
+	path := os.Getenv("WARMUP_FILE")
+	if path == "" {
+		return nil
+	}
+	if s.cepCache == nil && s.temperatureCache == nil {
+		log.Println("Aquecimento ignorado: os caches estão desativados")
+		return nil
+	}
+
+	ceps, err := LoadWarmUpCEPs(path)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, envDuration("WARMUP_TIMEOUT", time.Minute))
+	defer cancel()
+	s.WarmUp(ctx, ceps, envInt("WARMUP_CONCURRENCY", 4))
+	return nil
+}
+
+// WarmUp resolve os CEPs e suas temperaturas, preenchendo os caches. Roda até
+// concurrency consultas ao mesmo tempo e para quando o contexto termina.
+func (s *WeatherService) WarmUp(ctx context.Context, ceps []string, concurrency int) WarmUpResult {
+	ctx, span := s.tracer.Start(ctx, "cache-warmup")
+	defer span.End()
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	log.Printf("Aquecendo caches com %d CEPs (%d consultas simultâneas)", len(ceps), concurrency)
+
+	start := time.Now()
+	var done, failed atomic.Int64
+	progressStep := max(len(ceps)/10, 1)
+
+	queue := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for cep := range queue {
+				if err := s.warmUpCEP(ctx, cep); err != nil {
+					failed.Add(1)
+					log.Printf("Aquecimento: erro no CEP %s: %v", cep, err)
+				}
+				if n := done.Add(1); n%int64(progressStep) == 0 {
+					log.Printf("Aquecimento: %d de %d CEPs (%d falhas)", n, len(ceps), failed.Load())
+				}
+			}
+		}()
+	}
+
+	timedOut := false
+	for _, cep := range ceps {
+		select {
+		case queue <- cep:
+			continue
+		case <-ctx.Done():
+			timedOut = true
+		}
+		break
+	}
+	close(queue)
+	wg.Wait()
+
+	result := WarmUpResult{
+		Total:    len(ceps),
+		Resolved: int(done.Load() - failed.Load()),
+		Failed:   int(failed.Load()),
+		TimedOut: timedOut || ctx.Err() != nil,
+		Duration: time.Since(start),
+	}
+	span.SetAttributes(
+		attribute.Int("warmup.total", result.Total),
+		attribute.Int("warmup.resolved", result.Resolved),
+		attribute.Int("warmup.failed", result.Failed),
+		attribute.Bool("warmup.timed_out", result.TimedOut),
+	)
+	if result.TimedOut {
+		log.Printf("Aquecimento interrompido pelo prazo após %s: %d de %d CEPs resolvidos", result.Duration.Round(time.Millisecond), result.Resolved, result.Total)
+	} else {
+		log.Printf("Aquecimento concluído em %s: %d de %d CEPs resolvidos", result.Duration.Round(time.Millisecond), result.Resolved, result.Total)
+	}
+	return result
+}
+
+// warmUpCEP resolve o CEP e a temperatura da sua cidade, o que grava os dois nos caches
+func (s *WeatherService) warmUpCEP(ctx context.Context, cep string) error {
+	ctx = faults.WithCEP(ctx, cep)
+	address, err := s.GetCityByCEP(ctx, cep)
+	if err != nil {
+		return err
+	}
+	_, err = s.GetTemperature(ctx, address)
+	return err
+}
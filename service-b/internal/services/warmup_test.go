@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"service-b/internal/cache"
+	"service-b/internal/models"
+
+	"go.opentelemetry.io/otel"
+)
+
+func TestLoadWarmUpCEPs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ceps.txt")
+	os.WriteFile(path, []byte("# CEPs mais consultados\n01001-000\n\n  29902555 \n"), 0o644)
+
+	ceps, err := LoadWarmUpCEPs(path)
+	if err != nil {
+		t.Fatalf("Erro inesperado: %v", err)
+	}
+	if want := []string{"01001000", "29902555"}; !reflect.DeepEqual(ceps, want) {
+		t.Errorf("CEPs incorretos: obtido %v, esperado %v", ceps, want)
+	}
+
+	os.WriteFile(path, []byte("01001000\n123\n"), 0o644)
+	if _, err := LoadWarmUpCEPs(path); err == nil {
+		t.Errorf("Esperado erro para CEP inválido")
+	}
+}
+
+func TestWarmUp(t *testing.T) {
+	recordSpans(t)
+
+	provider := &fakeProvider{name: "weatherapi", tempC: 25}
+	service := &WeatherService{
+		tracer:           otel.GetTracerProvider().Tracer("weather-service"),
+		cepProvider:      &fakeProvider{cities: map[string]string{"01001000": "São Paulo", "01310100": "São Paulo", "29902555": "Linhares"}},
+		weatherProvider:  provider,
+		coordinates:      &CoordinateTable{},
+		cepCache:         cache.NewStore[models.Address]("cep", cache.NewMemory(10), "", cache.JSONCodec{}),
+		cepCacheTTL:      time.Minute,
+		temperatureCache: newTemperatureCache(newMemoryTemperatureStore(), time.Minute, time.Minute, 0),
+	}
+
+	result := service.WarmUp(context.Background(), []string{"01001000", "01310100", "29902555", "99999999"}, 3)
+	if result.Total != 4 || result.Resolved != 3 || result.Failed != 1 || result.TimedOut {
+		t.Errorf("Resultado incorreto: %+v", result)
+	}
+
+	ctx := context.Background()
+	for _, cep := range []string{"01001000", "29902555"} {
+		if _, ok := service.cepCache.Get(ctx, cep); !ok {
+			t.Errorf("CEP %s deveria estar em cache", cep)
+		}
+	}
+	if entries, _ := service.temperatureCache.entries.Entries(ctx); len(entries) != 2 {
+		t.Errorf("Deveria haver temperaturas de 2 cidades em cache: %+v", entries)
+	}
+
+	t.Run("prazo esgotado", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if result := service.WarmUp(ctx, []string{"01001000"}, 1); !result.TimedOut {
+			t.Errorf("Aquecimento deveria ser interrompido: %+v", result)
+		}
+	})
+}
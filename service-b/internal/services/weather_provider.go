@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"service-b/internal/models"
+)
+
+// WeatherProvider obtém a temperatura atual de uma localidade
+type WeatherProvider interface {
+	// Name identifica o provedor em logs e spans
+	Name() string
+	// Current retorna a leitura atual para a localidade consultada
+	Current(ctx context.Context, query models.WeatherQuery) (*models.WeatherReading, error)
+}
+
+// NewWeatherProvider cria o provedor de clima correspondente ao nome informado
+func NewWeatherProvider(name string, clients ClientFactory) (WeatherProvider, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "weatherapi":
+		return NewWeatherAPIProvider(envOrDefault("WEATHERAPI_URL", weatherAPIURL), os.Getenv("WEATHER_API_KEY"), clients.client("weatherapi")), nil
+	case "openmeteo", "open-meteo":
+		return NewOpenMeteoProvider(
+			envOrDefault("OPENMETEO_URL", openMeteoURL),
+			envOrDefault("OPENMETEO_GEOCODING_URL", openMeteoGeocodingURL),
+			clients.client("openmeteo"),
+		), nil
+	case "fixture", "fake":
+		return NewFixtureWeatherProvider(os.Getenv("WEATHER_FIXTURES_FILE"))
+	default:
+		return nil, fmt.Errorf("unknown weather provider: %s", name)
+	}
+}
+
+// NewWeatherProviders cria os provedores de uma lista separada por vírgulas, como
+// "weatherapi,openmeteo". O primeiro é o provedor principal; os demais são usados
+// no modo de consenso. Uma lista vazia usa apenas a WeatherAPI.
+func NewWeatherProviders(spec string, clients ClientFactory) ([]WeatherProvider, error) {
+	var providers []WeatherProvider
+	for _, name := range strings.Split(spec, ",") {
+		if strings.TrimSpace(name) == "" && len(providers) > 0 {
+			continue
+		}
+		provider, err := NewWeatherProvider(name, clients)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// Função para remover acentos de uma string
+func removeAccents(texto string) string {
+	replacements := map[string]string{
+		"á": "a", "à": "a", "ã": "a", "â": "a", "ä": "a",
+		"é": "e", "è": "e", "ê": "e", "ë": "e",
+		"í": "i", "ì": "i", "î": "i", "ï": "i",
+		"ó": "o", "ò": "o", "õ": "o", "ô": "o", "ö": "o",
+		"ú": "u", "ù": "u", "û": "u", "ü": "u",
+		"ç": "c",
+		"Á": "A", "À": "A", "Ã": "A", "Â": "A", "Ä": "A",
+		"É": "E", "È": "E", "Ê": "E", "Ë": "E",
+		"Í": "I", "Ì": "I", "Î": "I", "Ï": "I",
+		"Ó": "O", "Ò": "O", "Õ": "O", "Ô": "O", "Ö": "O",
+		"Ú": "U", "Ù": "U", "Û": "U", "Ü": "U",
+		"Ç": "C",
+	}
+
+	result := texto
+	for from, to := range replacements {
+		result = strings.Replace(result, from, to, -1)
+	}
+	return result
+}
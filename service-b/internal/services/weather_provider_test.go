@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"service-b/internal/models"
+)
+
+// mockWeatherServer simula a WeatherAPI e as APIs de geocodificação e previsão da Open-Meteo
+func mockWeatherServer(t *testing.T) *httptest.Server {
+	handler := http.NewServeMux()
+
+	handler.HandleFunc("/v1/current.json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if q := r.URL.Query().Get("q"); q != "Sao Paulo" {
+			t.Errorf("Consulta incorreta: %s", q)
+		}
+		w.Write([]byte(`{"location":{"name":"Sao Paulo","region":"Sao Paulo","country":"Brazil"},"current":{"temp_c":21.5,"condition":{"text":"Sunny"}}}`))
+	})
+
+	handler.HandleFunc("/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("name") != "São Paulo" {
+			w.Write([]byte(`{}`))
+			return
+		}
+		w.Write([]byte(`{"results":[{"name":"São Paulo","latitude":-23.55,"longitude":-46.63,"country_code":"BR","admin1":"São Paulo"}]}`))
+	})
+
+	handler.HandleFunc("/v1/forecast", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("latitude") != "-23.550000" {
+			t.Errorf("Latitude incorreta: %s", r.URL.Query().Get("latitude"))
+		}
+		w.Write([]byte(`{"current":{"time":"2025-05-20T12:00","interval":900,"temperature_2m":19.8,"weather_code":3}}`))
+	})
+
+	return httptest.NewServer(handler)
+}
+
+func TestWeatherProviders(t *testing.T) {
+	server := mockWeatherServer(t)
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		provider  WeatherProvider
+		tempC     float64
+		condition string
+	}{
+		{
+			name:      "weatherapi",
+			provider:  NewWeatherAPIProvider(server.URL+"/v1/current.json?key=%s&q=%s", "chave", http.DefaultClient),
+			tempC:     21.5,
+			condition: "Sunny",
+		},
+		{
+			name: "openmeteo",
+			provider: NewOpenMeteoProvider(
+				server.URL+"/v1/forecast?latitude=%f&longitude=%f",
+				server.URL+"/v1/search?name=%s",
+				http.DefaultClient,
+			),
+			tempC:     19.8,
+			condition: "Overcast",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reading, err := tt.provider.Current(context.Background(), models.WeatherQuery{City: "São Paulo", Uf: "SP"})
+			if err != nil {
+				t.Fatalf("Erro inesperado: %v", err)
+			}
+			if reading.TempC != tt.tempC || reading.Condition != tt.condition {
+				t.Errorf("Leitura incorreta: %+v", reading)
+			}
+			if reading.Provider != tt.name {
+				t.Errorf("Provedor incorreto: obtido %s, esperado %s", reading.Provider, tt.name)
+			}
+		})
+	}
+}
+
+func TestWeatherAPIWithoutKey(t *testing.T) {
+	provider := NewWeatherAPIProvider(weatherAPIURL, "", http.DefaultClient)
+	if _, err := provider.Current(context.Background(), models.WeatherQuery{City: "Linhares"}); err == nil {
+		t.Errorf("Esperado erro sem WEATHER_API_KEY")
+	}
+}
+
+func TestOpenMeteoCityNotFound(t *testing.T) {
+	server := mockWeatherServer(t)
+	defer server.Close()
+
+	provider := NewOpenMeteoProvider(server.URL+"/v1/forecast?latitude=%f&longitude=%f", server.URL+"/v1/search?name=%s", http.DefaultClient)
+	if _, err := provider.Current(context.Background(), models.WeatherQuery{City: "Cidade Inexistente"}); err == nil {
+		t.Errorf("Esperado erro para cidade inexistente")
+	}
+}
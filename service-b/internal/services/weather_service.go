@@ -2,192 +2,523 @@ package services
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
-	"strings"
+	"time"
 
+	"cep-weather-api/pkg/breaker"
+	"cep-weather-api/pkg/faults"
+	"service-b/internal/cache"
 	"service-b/internal/models"
+	"service-b/internal/ratelimit"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
-const (
-	viaCEPURL     = "https://viacep.com.br/ws/%s/json/"
-	weatherAPIURL = "http://api.weatherapi.com/v1/current.json?key=%s&q=%s&aqi=no"
-)
-
 // WeatherService implementa as operações para buscar cidade por CEP e temperatura
 type WeatherService struct {
-	testMode bool
-	client   *http.Client
-	tracer   trace.Tracer
+	tracer          trace.Tracer
+	cepProvider     CEPProvider
+	weatherProvider WeatherProvider
+	coordinates     *CoordinateTable
+
+	// Modo de consenso: consulta todos os provedores e usa a mediana
+	weatherProviders []WeatherProvider
+	consensus        bool
+	consensusTimeout time.Duration
+
+	// Hedging: dispara a consulta no provedor seguinte quando o atual demora
+	hedger *Hedger
+
+	// Circuitos dos provedores e limitadores de cota dos provedores de clima (nil sem
+	// fábrica de clientes, como nos testes)
+	breakers *breaker.Registry
+	limiters *ratelimit.Registry
+
+	// Cache dos endereços por CEP (nil quando desativado)
+	cepCache    *cache.Store[models.Address]
+	cepCacheTTL time.Duration
+
+	// Cache negativo dos CEPs inexistentes, com validade própria (nil quando desativado)
+	notFoundCache *cache.Store[notFoundEntry]
+	notFoundTTL   time.Duration
+
+	// Cache das leituras de temperatura por localidade (nil quando desativado)
+	temperatureCache *temperatureCache
+
+	// Agrupamento das consultas simultâneas por CEP e por localidade
+	cepFlights         *flightGroup[*models.Address]
+	temperatureFlights *flightGroup[*models.WeatherReading]
 }
 
-// NewWeatherService cria uma nova instância do serviço
-func NewWeatherService() *WeatherService {
-	// Verificar modo de teste
-	testMode := false
-	if os.Getenv("TEST_MODE") == "true" {
-		testMode = true
+// NewWeatherService cria uma nova instância do serviço a partir das variáveis de ambiente:
+//   - CEP_PROVIDER: provedores de CEP em ordem de preferência, separados por vírgula
+//     (viacep, brasilapi, opencep ou offline, que usa a base de CEP_DATASET_FILE)
+//   - WEATHER_PROVIDER: provedores de clima (weatherapi, openmeteo, que não exige chave,
+//     ou fixture, que responde a partir de WEATHER_FIXTURES_FILE e é o padrão com
+//     TEST_MODE=true); o primeiro é o principal e, com WEATHER_MODE=consensus, todos
+//     são consultados juntos dentro de WEATHER_CONSENSUS_TIMEOUT
+//   - IBGE_COORDINATES_FILE: complementa a tabela de coordenadas por código IBGE
+//   - HEDGE_DELAY: uma duração ou "p95"; ativa o hedging entre os provedores de CEP
+//     e entre os dois primeiros provedores de clima
+//   - CEP_CACHE_SIZE e CEP_CACHE_TTL: capacidade e validade do cache de endereços;
+//     tamanho 0 desativa o cache
+//   - CEP_NOT_FOUND_TTL: por quanto tempo um CEP inexistente é lembrado; 0 desativa
+//   - WEATHER_CACHE_SIZE, WEATHER_CACHE_TTL e WEATHER_CACHE_STALE: capacidade, validade
+//     máxima e janela em que uma leitura vencida ainda é servida enquanto é atualizada
+//   - WEATHER_LAST_KNOWN_MAX_AGE: idade máxima da última leitura conhecida usada nas
+//     respostas degradadas; 0 desativa
+//
+// As chamadas aos provedores passam pelo injetor de falhas informado, que pode ser nil.
+func NewWeatherService(injector *faults.Injector) (*WeatherService, error) {
+
+	caches, err := newCacheConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// As cotas mensais são contadas no Redis, compartilhado entre as réplicas
+	var counter ratelimit.Counter
+	if caches.redis != nil {
+		counter = caches.redis
+	}
+
+	breakers := NewBreakerRegistry()
+	limiters := NewRateLimitRegistry(counter, caches.prefix)
+	clients := NewClientFactory(injector, breakers, limiters)
+
+	cepProvider, err := NewCEPProviderChainFromSpec(os.Getenv("CEP_PROVIDER"), clients, injector)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Provedores de CEP: %s", cepProvider.Name())
+
+	hedger := NewHedger(os.Getenv("HEDGE_DELAY"), envDuration("HEDGE_FALLBACK_DELAY", 500*time.Millisecond))
+	if hedger != nil {
+		log.Printf("Hedging ativado (HEDGE_DELAY=%s)", os.Getenv("HEDGE_DELAY"))
+		cepProvider.hedger = hedger
+	}
+
+	// Em modo de teste o provedor padrão é o de fixtures, que não acessa a rede
+	weatherSpec := os.Getenv("WEATHER_PROVIDER")
+	if os.Getenv("TEST_MODE") == "true" && weatherSpec == "" {
 		log.Println("Iniciando serviço em modo de teste")
+		weatherSpec = "fixture"
 	}
 
-	return &WeatherService{
-		testMode: testMode,
-		client:   &http.Client{},
-		tracer:   otel.GetTracerProvider().Tracer("weather-service"),
+	weatherProviders, err := NewWeatherProviders(weatherSpec, clients)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkMonthlyQuotas(weatherProviders, counter != nil); err != nil {
+		return nil, err
+	}
+
+	consensus := os.Getenv("WEATHER_MODE") == "consensus"
+	if consensus {
+		log.Printf("Modo de consenso com %d provedores de clima", len(weatherProviders))
+	} else {
+		log.Printf("Provedor de clima: %s", weatherProviders[0].Name())
+	}
+
+	coordinates, err := NewCoordinateTable(os.Getenv("IBGE_COORDINATES_FILE"))
+	if err != nil {
+		return nil, err
 	}
+	log.Printf("Tabela de coordenadas carregada com %d municípios", coordinates.Len())
+
+	var cepCache *cache.Store[models.Address]
+	if size := envInt("CEP_CACHE_SIZE", 10000); size > 0 {
+		cepCache = cache.NewStore[models.Address]("cep", caches.persistentBackend(size), caches.prefix, caches.codec)
+	}
+
+	var notFoundCache *cache.Store[notFoundEntry]
+	notFoundTTL := envDuration("CEP_NOT_FOUND_TTL", time.Hour)
+	if size := envInt("CEP_CACHE_SIZE", 10000); size > 0 && notFoundTTL > 0 {
+		notFoundCache = cache.NewStore[notFoundEntry]("cep-not-found", caches.persistentBackend(size), caches.prefix, caches.codec)
+	}
+
+	var temperatureCache *temperatureCache
+	if size := envInt("WEATHER_CACHE_SIZE", 1000); size > 0 {
+		temperatureCache = newTemperatureCache(
+			cache.NewStore[temperatureEntry]("weather", caches.backend(size), caches.prefix, caches.codec),
+			envDuration("WEATHER_CACHE_TTL", 5*time.Minute),
+			envDuration("WEATHER_CACHE_STALE", 10*time.Minute),
+			envDuration("WEATHER_LAST_KNOWN_MAX_AGE", time.Hour))
+	}
+
+	tracer := otel.GetTracerProvider().Tracer("weather-service")
+	return &WeatherService{
+		tracer:          tracer,
+		cepProvider:     cepProvider,
+		weatherProvider: weatherProviders[0],
+		coordinates:     coordinates,
+
+		weatherProviders: weatherProviders,
+		consensus:        consensus,
+		consensusTimeout: envDuration("WEATHER_CONSENSUS_TIMEOUT", 3*time.Second),
+
+		hedger:   hedger,
+		breakers: breakers,
+		limiters: limiters,
+
+		cepCache:         cepCache,
+		cepCacheTTL:      envDuration("CEP_CACHE_TTL", 24*time.Hour),
+		notFoundCache:    notFoundCache,
+		notFoundTTL:      notFoundTTL,
+		temperatureCache: temperatureCache,
+
+		cepFlights:         newFlightGroup[*models.Address](tracer, "cep-lookup"),
+		temperatureFlights: newFlightGroup[*models.WeatherReading](tracer, "weather-lookup"),
+	}, nil
 }
 
-// GetCityByCEP busca uma cidade com base no CEP
-func (s *WeatherService) GetCityByCEP(ctx context.Context, cep string) (string, error) {
+// GetCityByCEP busca a cidade (e o restante do endereço) com base no CEP
+func (s *WeatherService) GetCityByCEP(ctx context.Context, cep string) (*models.Address, error) {
 	ctx, span := s.tracer.Start(ctx, "get-city-by-cep")
 	defer span.End()
 
 	span.SetAttributes(attribute.String("cep", cep))
 
-	// Para testes: simular CEP não encontrado
-	if os.Getenv("SIMULATE_CEP_NOT_FOUND") == "true" {
-		return "", fmt.Errorf("CEP not found")
+	address, hit := s.cachedAddress(ctx, cep)
+	if !hit && s.cachedNotFound(ctx, cep) {
+		span.SetAttributes(attribute.Bool("cache.hit", true), attribute.Bool("cache.negative", true))
+		span.RecordError(ErrCEPNotFound)
+		return nil, ErrCEPNotFound
+	}
+	span.SetAttributes(attribute.Bool("cache.hit", hit))
+	if !hit {
+		// Requisições simultâneas para o mesmo CEP compartilham a consulta
+		shared, err := s.cepFlights.Do(ctx, cep, func(ctx context.Context, _ trace.Span) (*models.Address, error) {
+			ctx, injected := faults.TrackInjected(ctx)
+			address, err := s.cepProvider.Lookup(ctx, cep)
+			if err == nil && address.Localidade == "" {
+				err = fmt.Errorf("%w: city not found", ErrCEPNotFound)
+			}
+			if err != nil {
+				// Só a resposta definitiva de CEP inexistente é lembrada; falhas de rede
+				// e de provedor, assim como respostas de regras de falha, voltam a ser
+				// consultadas na próxima requisição
+				if errors.Is(err, ErrCEPNotFound) && s.notFoundCache != nil && !injected() {
+					s.notFoundCache.Set(ctx, cep, notFoundEntry{At: time.Now()}, s.notFoundTTL)
+				}
+				return nil, err
+			}
+			if s.cepCache != nil {
+				s.cepCache.Set(ctx, cep, *address, s.cepCacheTTL)
+			}
+			return address, nil
+		})
+		if err != nil {
+			span.RecordError(err)
+			return nil, err
+		}
+		result := *shared
+		address = &result
 	}
 
-	url := fmt.Sprintf(viaCEPURL, cep)
-	log.Printf("Consultando CEP: %s", url)
+	log.Printf("Cidade encontrada: %s", address.Localidade)
+	span.SetAttributes(
+		attribute.String("city", address.Localidade),
+		attribute.String("cep.provider", address.Provider),
+	)
+	return address, nil
+}
 
-	// Criar requisição com contexto
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		span.RecordError(err)
-		return "", err
+// cachedAddress retorna uma cópia do endereço em cache para o CEP, se houver
+func (s *WeatherService) cachedAddress(ctx context.Context, cep string) (*models.Address, bool) {
+	if s.cepCache == nil {
+		return nil, false
+	}
+	address, ok := s.cepCache.Get(ctx, cep)
+	if !ok {
+		return nil, false
 	}
+	return &address, true
+}
 
-	// Executar requisição
-	resp, err := s.client.Do(req)
-	if err != nil {
-		log.Printf("Erro ao consultar ViaCEP: %v", err)
-		span.RecordError(err)
-		return "", err
+// Registro de um CEP que os provedores responderam não existir
+type notFoundEntry struct {
+	At time.Time `json:"at"`
+}
+
+// cachedNotFound informa se o CEP foi respondido como inexistente há menos de notFoundTTL
+func (s *WeatherService) cachedNotFound(ctx context.Context, cep string) bool {
+	if s.notFoundCache == nil {
+		return false
 	}
-	defer resp.Body.Close()
+	_, ok := s.notFoundCache.Get(ctx, cep)
+	return ok
+}
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("ViaCEP retornou status code: %d", resp.StatusCode)
-		err := fmt.Errorf("CEP not found")
-		span.RecordError(err)
-		return "", err
+// CacheStats retorna os contadores de cada cache do serviço, pelo nome do cache
+func (s *WeatherService) CacheStats() map[string]cache.Stats {
+	stats := map[string]cache.Stats{}
+	if s.cepCache != nil {
+		stats[s.cepCache.Name()] = s.cepCache.Stats()
+	}
+	if s.notFoundCache != nil {
+		stats[s.notFoundCache.Name()] = s.notFoundCache.Stats()
 	}
+	if s.temperatureCache != nil {
+		stats[s.temperatureCache.entries.Name()] = s.temperatureCache.entries.Stats()
+	}
+	return stats
+}
 
-	var viaCEPResp models.ViaCEPResponse
-	if err := json.NewDecoder(resp.Body).Decode(&viaCEPResp); err != nil {
-		log.Printf("Erro ao decodificar resposta do ViaCEP: %v", err)
-		span.RecordError(err)
-		return "", err
+// GetTemperature busca a temperatura para a cidade do endereço.
+// Nas consultas pelo nome, confere se a localidade usada pelo provedor está na UF do CEP
+// e repete a consulta com o nome qualificado ("cidade, UF, Brazil") em caso de divergência.
+func (s *WeatherService) GetTemperature(ctx context.Context, address *models.Address) (*models.WeatherReading, error) {
+	ctx, span := s.tracer.Start(ctx, "get-temperature")
+	defer span.End()
+
+	span.SetAttributes(attribute.String("city", address.Localidade))
+
+	query := s.weatherQuery(address)
+	if query.Coordinates != nil {
+		span.SetAttributes(
+			attribute.String("weather.query", "coordinates"),
+			attribute.Float64("lat", query.Coordinates.Lat),
+			attribute.Float64("lon", query.Coordinates.Lon),
+		)
+	} else {
+		span.SetAttributes(attribute.String("weather.query", "name"))
 	}
 
-	// Checar se a resposta contém erro
-	if viaCEPResp.Erro {
-		err := fmt.Errorf("CEP not found")
-		span.RecordError(err)
-		return "", err
+	key := temperatureKey(query)
+	if s.temperatureCache != nil {
+		reading, status := s.temperatureCache.get(ctx, key)
+		span.SetAttributes(
+			attribute.Bool("cache.hit", status != CacheMiss),
+			attribute.Bool("cache.stale", status == CacheStale),
+		)
+		if status != CacheMiss {
+			// Leitura vencida é servida enquanto uma atualização roda em segundo plano
+			if status == CacheStale {
+				s.refreshTemperature(ctx, key, query)
+			}
+			reading.CacheStatus = status
+			span.SetAttributes(
+				attribute.Float64("temperature_c", reading.TempC),
+				attribute.String("location.confidence", reading.LocationConfidence),
+			)
+			return reading, nil
+		}
 	}
 
-	if viaCEPResp.Localidade == "" {
-		err := fmt.Errorf("City not found")
+	// Requisições simultâneas para a mesma localidade compartilham a consulta
+	shared, err := s.temperatureFlights.Do(ctx, key, func(ctx context.Context, span trace.Span) (*models.WeatherReading, error) {
+		reading, err := s.readTemperature(ctx, span, query)
+		if err == nil && s.temperatureCache != nil {
+			s.temperatureCache.put(ctx, key, query.City, reading)
+		}
+		return reading, err
+	})
+	if err != nil {
 		span.RecordError(err)
-		return "", err
+		return nil, err
+	}
+	result := *shared
+	reading := &result
+	if s.temperatureCache != nil {
+		reading.CacheStatus = CacheMiss
 	}
 
-	log.Printf("Cidade encontrada: %s", viaCEPResp.Localidade)
-	span.SetAttributes(attribute.String("city", viaCEPResp.Localidade))
-	return viaCEPResp.Localidade, nil
+	// Registrar a temperatura encontrada
+	span.SetAttributes(
+		attribute.Float64("temperature_c", reading.TempC),
+		attribute.String("location.confidence", reading.LocationConfidence),
+	)
+	return reading, nil
 }
 
-// GetTemperature busca a temperatura para uma cidade
-func (s *WeatherService) GetTemperature(ctx context.Context, cidade string) (float64, error) {
-	ctx, span := s.tracer.Start(ctx, "get-temperature")
-	defer span.End()
+// LastKnownTemperature retorna a última leitura em cache da localidade do endereço, mesmo
+// vencida, e o momento da observação, para as respostas degradadas quando os provedores
+// de clima falham
+func (s *WeatherService) LastKnownTemperature(ctx context.Context, address *models.Address) (*models.WeatherReading, time.Time, bool) {
+	if s.temperatureCache == nil {
+		return nil, time.Time{}, false
+	}
+	return s.temperatureCache.lastKnownReading(ctx, temperatureKey(s.weatherQuery(address)))
+}
 
-	span.SetAttributes(attribute.String("city", cidade))
+// readTemperature consulta os provedores de clima conforme o modo configurado:
+// consenso, hedging entre os dois primeiros ou apenas o principal
+func (s *WeatherService) readTemperature(ctx context.Context, span trace.Span, query models.WeatherQuery) (*models.WeatherReading, error) {
+	if s.consensus {
+		span.SetAttributes(attribute.String("weather.mode", "consensus"))
+		return s.consensusReading(ctx, query)
+	}
 
-	// Modo de teste retorna valor fictício para facilitar testes
-	if s.testMode {
-		log.Printf("Usando modo de teste para cidade: %s", cidade)
-		return 25.0, nil
+	if s.hedger != nil && len(s.weatherProviders) > 1 {
+		reading, err := s.hedgedReading(ctx, query)
+		if err == nil {
+			span.SetAttributes(attribute.String("weather.provider", reading.Provider))
+		}
+		return reading, err
 	}
 
-	apiKey := os.Getenv("WEATHER_API_KEY")
-	if apiKey == "" {
-		err := fmt.Errorf("WEATHER_API_KEY not set")
-		span.RecordError(err)
-		return 0, err
+	span.SetAttributes(attribute.String("weather.provider", s.weatherProvider.Name()))
+	return s.fetchReading(ctx, span, s.weatherProvider, query)
+}
+
+// refreshTemperature atualiza em segundo plano a leitura vencida da chave, se nenhuma
+// outra atualização estiver em andamento. O span da atualização é uma nova raiz,
+// ligada ao span da requisição que a disparou.
+func (s *WeatherService) refreshTemperature(ctx context.Context, key string, query models.WeatherQuery) {
+	if !s.temperatureCache.startRefresh(key) {
+		return
 	}
 
-	// Normaliza a string removendo acentos
-	encodedCidade := s.removeAccents(cidade)
+	link := trace.LinkFromContext(ctx)
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer s.temperatureCache.finishRefresh(key)
+
+		ctx, cancel := context.WithTimeout(ctx, temperatureRefreshTimeout)
+		defer cancel()
+		ctx, span := s.tracer.Start(ctx, "refresh-temperature", trace.WithNewRoot(), trace.WithLinks(link))
+		defer span.End()
+		span.SetAttributes(attribute.String("cache.key", key))
 
-	url := fmt.Sprintf(weatherAPIURL, apiKey, encodedCidade)
-	log.Printf("Consultando temperatura para %s: %s", cidade, url)
+		reading, err := s.readTemperature(ctx, span, query)
+		if err != nil {
+			log.Printf("Erro ao atualizar temperatura em cache (%s): %v", key, err)
+			span.RecordError(err)
+			return
+		}
+		s.temperatureCache.put(ctx, key, query.City, reading)
+	}()
+}
 
-	// Criar requisição com contexto
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// fetchReading consulta um provedor de clima e define a confiança da localidade da leitura
+func (s *WeatherService) fetchReading(ctx context.Context, span trace.Span, provider WeatherProvider, query models.WeatherQuery) (*models.WeatherReading, error) {
+	reading, err := provider.Current(ctx, query)
 	if err != nil {
-		span.RecordError(err)
-		return 0, err
+		return nil, err
+	}
+
+	// Coordenadas vêm do próprio CEP; só as consultas pelo nome precisam ser conferidas
+	if query.Coordinates != nil {
+		reading.LocationConfidence = LocationConfidenceHigh
+		return reading, nil
+	}
+	return s.verifyLocation(ctx, span, provider, query, reading)
+}
+
+// hedgedReading consulta o provedor principal e, se ele não responder dentro do atraso
+// do Hedger (ou falhar), o secundário. A primeira leitura bem-sucedida vence e a outra
+// consulta é cancelada. Cada tentativa tem seu próprio span.
+func (s *WeatherService) hedgedReading(ctx context.Context, query models.WeatherQuery) (*models.WeatherReading, error) {
+	providers := s.weatherProviders[:2]
+
+	attempts := make([]attemptFunc[*models.WeatherReading], len(providers))
+	for i, provider := range providers {
+		attempts[i] = func(ctx context.Context, hedged bool) (*models.WeatherReading, error) {
+			ctx, span := s.tracer.Start(ctx, "weather-provider-attempt")
+			defer span.End()
+			span.SetAttributes(
+				attribute.String("weather.provider", provider.Name()),
+				attribute.Int("weather.attempt", i+1),
+				attribute.Bool("hedged", hedged),
+			)
+
+			start := time.Now()
+			reading, err := s.fetchReading(ctx, span, provider, query)
+			switch {
+			case err == nil:
+				span.SetAttributes(attribute.String("weather.outcome", "success"))
+				s.hedger.Observe(provider.Name(), time.Since(start))
+			case ctx.Err() != nil:
+				span.SetAttributes(attribute.String("weather.outcome", "cancelled"))
+			default:
+				span.SetAttributes(attribute.String("weather.outcome", "error"))
+				span.RecordError(err)
+			}
+			return reading, err
+		}
+	}
+
+	delay := func(i int) time.Duration { return s.hedger.Delay(providers[i].Name()) }
+	reading, _, err := raceAttempts(ctx, attempts, delay, func(error) bool { return false })
+	return reading, err
+}
+
+// verifyLocation confere a UF e o país da leitura e, se divergirem do CEP, refaz a
+// consulta com o nome qualificado. Divergências que persistem geram um evento no span.
+func (s *WeatherService) verifyLocation(ctx context.Context, span trace.Span, provider WeatherProvider, query models.WeatherQuery, reading *models.WeatherReading) (*models.WeatherReading, error) {
+	match, known := locationMatches(query.Uf, reading.Location)
+	if !known {
+		reading.LocationConfidence = LocationConfidenceUnverified
+		return reading, nil
+	}
+	if match {
+		reading.LocationConfidence = LocationConfidenceHigh
+		return reading, nil
 	}
 
-	// Executar requisição
-	resp, err := s.client.Do(req)
+	log.Printf("Localidade %s/%s não corresponde a %s/%s, repetindo consulta qualificada",
+		reading.Location.Name, reading.Location.Region, query.City, query.Uf)
+	span.AddEvent("location-retry", trace.WithAttributes(
+		attribute.String("location.name", reading.Location.Name),
+		attribute.String("location.region", reading.Location.Region),
+		attribute.String("location.country", reading.Location.Country),
+	))
+
+	query.Qualified = true
+	retried, err := provider.Current(ctx, query)
 	if err != nil {
-		log.Printf("Erro ao consultar API: %v", err)
-		span.RecordError(err)
-		return 0, err
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("API retornou status code: %d", resp.StatusCode)
-		err := fmt.Errorf("Error getting weather data: status %d", resp.StatusCode)
-		span.RecordError(err)
-		return 0, err
+	match, known = locationMatches(query.Uf, retried.Location)
+	switch {
+	case match:
+		retried.LocationConfidence = LocationConfidenceHigh
+	case !known:
+		retried.LocationConfidence = LocationConfidenceUnverified
+	default:
+		retried.LocationConfidence = LocationConfidenceLow
+		span.AddEvent("location-mismatch", trace.WithAttributes(
+			attribute.String("expected.uf", query.Uf),
+			attribute.String("location.name", retried.Location.Name),
+			attribute.String("location.region", retried.Location.Region),
+			attribute.String("location.country", retried.Location.Country),
+		))
 	}
+	return retried, nil
+}
 
-	var weatherResp models.WeatherAPIResponse
-	if err := json.NewDecoder(resp.Body).Decode(&weatherResp); err != nil {
-		log.Printf("Erro ao decodificar resposta: %v", err)
-		span.RecordError(err)
-		return 0, err
+// weatherQuery monta a consulta de clima, preferindo as coordenadas informadas pelo
+// provedor de CEP e, na falta delas, as da tabela por código IBGE. Sem nenhuma das
+// duas, a consulta é feita apenas pelo nome da cidade.
+func (s *WeatherService) weatherQuery(address *models.Address) models.WeatherQuery {
+	query := models.WeatherQuery{City: address.Localidade, Uf: address.Uf}
+
+	if address.Coordinates != nil {
+		query.Coordinates = address.Coordinates
+	} else if coords, ok := s.coordinates.Lookup(address.Ibge); ok && address.Ibge != "" {
+		query.Coordinates = &coords
 	}
+	return query
+}
 
-	// Registrar a temperatura encontrada
-	span.SetAttributes(attribute.Float64("temperature_c", weatherResp.Current.TempC))
-	return weatherResp.Current.TempC, nil
-}
-
-// Função para remover acentos de uma string
-func (s *WeatherService) removeAccents(texto string) string {
-	replacements := map[string]string{
-		"á": "a", "à": "a", "ã": "a", "â": "a", "ä": "a",
-		"é": "e", "è": "e", "ê": "e", "ë": "e",
-		"í": "i", "ì": "i", "î": "i", "ï": "i",
-		"ó": "o", "ò": "o", "õ": "o", "ô": "o", "ö": "o",
-		"ú": "u", "ù": "u", "û": "u", "ü": "u",
-		"ç": "c",
-		"Á": "A", "À": "A", "Ã": "A", "Â": "A", "Ä": "A",
-		"É": "E", "È": "E", "Ê": "E", "Ë": "E",
-		"Í": "I", "Ì": "I", "Î": "I", "Ï": "I",
-		"Ó": "O", "Ò": "O", "Õ": "O", "Ô": "O", "Ö": "O",
-		"Ú": "U", "Ù": "U", "Û": "U", "Ü": "U",
-		"Ç": "C",
-	}
-
-	result := texto
-	for from, to := range replacements {
-		result = strings.Replace(result, from, to, -1)
-	}
-	return result
+// BreakerStates retorna o estado do circuito de cada provedor já consultado
+func (s *WeatherService) BreakerStates() map[string]breaker.Snapshot {
+	return s.breakers.Snapshots()
+}
+
+// QuotaUsage retorna o consumo das cotas de cada provedor de clima já consultado
+func (s *WeatherService) QuotaUsage() map[string]ratelimit.Usage {
+	return s.limiters.Usage()
+}
+
+// OpenBreakers retorna os provedores com o circuito aberto ou meio aberto
+func (s *WeatherService) OpenBreakers() []string {
+	return s.breakers.Open()
 }
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"service-b/internal/models"
+)
+
+const (
+	weatherAPIURL = "http://api.weatherapi.com/v1/current.json?key=%s&q=%s&aqi=no"
+
+	// Intervalo entre as atualizações das observações da WeatherAPI
+	weatherAPIUpdateInterval = 15 * time.Minute
+)
+
+// WeatherAPIProvider consulta a WeatherAPI, que exige uma chave de API
+type WeatherAPIProvider struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewWeatherAPIProvider cria o provedor WeatherAPI a partir de uma URL com os placeholders da chave e da consulta
+func NewWeatherAPIProvider(url, apiKey string, client *http.Client) *WeatherAPIProvider {
+	return &WeatherAPIProvider{url: url, apiKey: apiKey, client: client}
+}
+
+// Name retorna o nome do provedor
+func (p *WeatherAPIProvider) Name() string {
+	return "weatherapi"
+}
+
+// Current busca a temperatura atual pelas coordenadas ou, na falta delas, pelo nome da cidade
+func (p *WeatherAPIProvider) Current(ctx context.Context, query models.WeatherQuery) (*models.WeatherReading, error) {
+	if p.apiKey == "" {
+		return nil, fmt.Errorf("WEATHER_API_KEY not set")
+	}
+
+	// Consulta por coordenadas quando disponíveis; senão pelo nome sem acentos
+	q := removeAccents(query.City)
+	if query.Coordinates != nil {
+		q = fmt.Sprintf("%.4f,%.4f", query.Coordinates.Lat, query.Coordinates.Lon)
+	} else if query.Qualified {
+		q = removeAccents(qualifiedCityName(query.City, query.Uf))
+	}
+
+	log.Printf("Consultando temperatura na WeatherAPI para %s (q=%s)", query.City, q)
+
+	var weatherResp models.WeatherAPIResponse
+	status, err := getJSON(ctx, p.client, fmt.Sprintf(p.url, p.apiKey, url.QueryEscape(q)), &weatherResp)
+	if err != nil {
+		log.Printf("Erro ao consultar API: %v", err)
+		return nil, err
+	}
+
+	if status != http.StatusOK {
+		log.Printf("API retornou status code: %d", status)
+		return nil, fmt.Errorf("Error getting weather data: status %d", status)
+	}
+
+	reading := &models.WeatherReading{
+		Provider:  p.Name(),
+		TempC:     weatherResp.Current.TempC,
+		Condition: weatherResp.Current.Condition.Text,
+		Location: &models.ResolvedLocation{
+			Name:    weatherResp.Location.Name,
+			Region:  weatherResp.Location.Region,
+			Country: weatherResp.Location.Country,
+			Lat:     weatherResp.Location.Lat,
+			Lon:     weatherResp.Location.Lon,
+		},
+		UpdateInterval: weatherAPIUpdateInterval,
+	}
+	if weatherResp.Current.LastUpdatedEpoch > 0 {
+		reading.ObservedAt = time.Unix(weatherResp.Current.LastUpdatedEpoch, 0)
+	}
+	return reading, nil
+}